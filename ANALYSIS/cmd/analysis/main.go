@@ -3,23 +3,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/config"
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/control"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/models"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/pipeline"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/quantify"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/rbridge"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/reference"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/stats"
+	"github.com/guidiju-50/pandora/SHARED/discovery"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -49,15 +54,34 @@ func main() {
 	// Initialize reference manager for Kallisto indices
 	referenceDir := getEnvOrDefault("REFERENCE_DIR", "/data/references")
 	kallistoPath := getEnvOrDefault("KALLISTO_PATH", "/opt/kallisto/kallisto")
-	refManager := reference.NewManager(referenceDir, kallistoPath, logger)
+	// Custom index builds and annotation extractions run in the background
+	// and are aborted on shutdown
+	buildCtx, stopBuilds := context.WithCancel(context.Background())
+	defer stopBuilds()
+	refManager := reference.NewManager(buildCtx, referenceDir, kallistoPath, logger)
+	diffAnalysis.SetAnnotator(refManager)
+
+	// Initialize service registry for inter-module calls
+	registry := discovery.NewRegistry(discovery.Config{
+		HealthInterval: cfg.Discovery.HealthInterval,
+		HealthTimeout:  cfg.Discovery.HealthTimeout,
+	}, logger)
+	registry.Register(discovery.ServiceProcessing, serviceURLs(cfg.Processing.URL, "PROCESSING_URL", "http://processing:8081")...)
+	registry.Register(discovery.ServiceControl, serviceURLs(cfg.Control.URL, "CONTROL_API_URL", "http://localhost:8080")...)
+
+	registryCtx, stopRegistry := context.WithCancel(context.Background())
+	defer stopRegistry()
+	registry.Start(registryCtx)
 
 	// Initialize pipeline orchestrator
-	processingURL := getEnvOrDefault("PROCESSING_URL", "http://processing:8081")
 	outputDir := getEnvOrDefault("OUTPUT_DIR", "/data/output")
-	orchestrator := pipeline.NewOrchestrator(processingURL, refManager, kallisto, matrixGen, outputDir, logger)
+	orchestrator := pipeline.NewOrchestrator(registry, refManager, kallisto, matrixGen, outputDir, logger)
+
+	// Results are recorded in CONTROL for versioning
+	controlClient := control.NewClient(cfg.Control, registry, logger)
 
 	// Setup router
-	router := setupRouter(logger, cfg, kallisto, rsem, rExecutor, diffAnalysis, matrixGen, refManager, orchestrator)
+	router := setupRouter(buildCtx, logger, cfg, kallisto, rsem, rExecutor, diffAnalysis, matrixGen, refManager, orchestrator, registry, controlClient)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
@@ -78,6 +102,7 @@ func main() {
 	<-quit
 
 	logger.Info("shutting down server...")
+	stopBuilds()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -108,6 +133,7 @@ func initLogger() *zap.Logger {
 }
 
 func setupRouter(
+	buildCtx context.Context,
 	logger *zap.Logger,
 	cfg *config.Config,
 	kallisto *quantify.Kallisto,
@@ -117,6 +143,8 @@ func setupRouter(
 	matrixGen *quantify.MatrixGenerator,
 	refManager *reference.Manager,
 	orchestrator *pipeline.Orchestrator,
+	registry *discovery.Registry,
+	controlClient *control.Client,
 ) *gin.Engine {
 	if os.Getenv("ENV") == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -144,6 +172,7 @@ func setupRouter(
 			quant.POST("/kallisto", handleKallistoQuant(logger, kallisto, cfg))
 			quant.POST("/rsem", handleRSEMQuant(logger, rsem, cfg))
 			quant.POST("/matrix", handleGenerateMatrix(logger, matrixGen))
+			quant.POST("/import", handleImportAbundance(logger, matrixGen, cfg, controlClient))
 		}
 
 		// Analysis
@@ -158,15 +187,20 @@ func setupRouter(
 		jobs := api.Group("/jobs")
 		{
 			jobs.POST("/quantify", handleQuantifyJob(logger, kallisto, rsem, cfg))
-			jobs.POST("/differential", handleDifferentialJob(logger, diffAnalysis))
+			jobs.POST("/differential", handleDifferentialJob(logger, diffAnalysis, refManager, controlClient))
 		}
 
+		// Service discovery
+		api.GET("/services", handleListServices(registry))
+
 		// Index/Reference management
 		refs := api.Group("/references")
 		{
 			refs.GET("", handleListOrganisms(logger, refManager))
 			refs.POST("/ensure", handleEnsureIndex(logger, refManager))
 			refs.POST("/custom", handleAddCustomOrganism(logger, refManager))
+			refs.POST("/upload", handleUploadReference(buildCtx, logger, refManager, cfg))
+			refs.GET("/builds/:id", handleGetReferenceBuild(refManager))
 		}
 
 		// Index management (legacy)
@@ -176,10 +210,17 @@ func setupRouter(
 		pipelineGroup := api.Group("/pipeline")
 		{
 			pipelineGroup.POST("/start", handleStartPipeline(logger, orchestrator))
+			pipelineGroup.POST("/sweep", handleStartSweep(logger, orchestrator))
+			pipelineGroup.POST("/saturation", handleStartSaturation(logger, orchestrator))
 			pipelineGroup.GET("/jobs", handleListPipelineJobs(logger, orchestrator))
 			pipelineGroup.GET("/jobs/:id", handleGetPipelineJob(logger, orchestrator))
 			pipelineGroup.GET("/jobs/:id/progress", handlePipelineProgress(logger, orchestrator))
 			pipelineGroup.POST("/jobs/:id/cancel", handleCancelPipelineJob(logger, orchestrator))
+
+			// Bulk job operations by filter
+			pipelineGroup.POST("/jobs/bulk/cancel", handleBulkCancelPipelineJobs(logger, orchestrator))
+			pipelineGroup.POST("/jobs/bulk/retry", handleBulkRetryPipelineJobs(logger, orchestrator))
+			pipelineGroup.POST("/jobs/bulk/delete", handleBulkDeletePipelineJobs(logger, orchestrator))
 		}
 	}
 
@@ -294,6 +335,7 @@ type DifferentialRequest struct {
 	Method          string  `json:"method"`
 	PValueThreshold float64 `json:"pvalue_threshold"`
 	Log2FCThreshold float64 `json:"log2fc_threshold"`
+	Organism        string  `json:"organism"`
 }
 
 func handleDifferential(logger *zap.Logger, da *stats.DifferentialAnalysis) gin.HandlerFunc {
@@ -319,6 +361,7 @@ func handleDifferential(logger *zap.Logger, da *stats.DifferentialAnalysis) gin.
 			Method:          req.Method,
 			PValueThreshold: req.PValueThreshold,
 			Log2FCThreshold: req.Log2FCThreshold,
+			Organism:        req.Organism,
 		}
 
 		result, err := da.Run(c.Request.Context(), opts)
@@ -446,7 +489,9 @@ func handleQuantifyJob(logger *zap.Logger, k *quantify.Kallisto, r *quantify.RSE
 	}
 }
 
-func handleDifferentialJob(logger *zap.Logger, da *stats.DifferentialAnalysis) gin.HandlerFunc {
+// handleDifferentialJob runs a DE job dispatched by CONTROL and, when the job
+// belongs to an experiment, records the result in CONTROL as a new version.
+func handleDifferentialJob(logger *zap.Logger, da *stats.DifferentialAnalysis, refManager *reference.Manager, controlClient *control.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
 			JobID string         `json:"job_id" binding:"required"`
@@ -466,6 +511,12 @@ func handleDifferentialJob(logger *zap.Logger, da *stats.DifferentialAnalysis) g
 			Method:          getString(req.Input, "method"),
 			PValueThreshold: getFloat(req.Input, "pvalue_threshold"),
 			Log2FCThreshold: getFloat(req.Input, "log2fc_threshold"),
+			Organism:        getString(req.Input, "organism"),
+		}
+
+		experimentID := getString(req.Input, "experiment_id")
+		if experimentID != "" {
+			opts.ExperimentID, _ = uuid.Parse(experimentID)
 		}
 
 		result, err := da.Run(c.Request.Context(), opts)
@@ -474,14 +525,57 @@ func handleDifferentialJob(logger *zap.Logger, da *stats.DifferentialAnalysis) g
 			return
 		}
 
+		recorded := false
+		if experimentID != "" {
+			data, err := control.ResultData(result)
+			if err == nil {
+				err = controlClient.RecordResult(c.Request.Context(), control.Result{
+					ExperimentID: experimentID,
+					JobID:        req.JobID,
+					Type:         control.ResultTypeDifferential,
+					Comparison:   result.Comparison,
+					Parameters:   deParameters(logger, refManager, result),
+					Data:         data,
+				})
+			}
+			if err != nil {
+				logger.Warn("failed to record result in CONTROL", zap.String("job_id", req.JobID), zap.Error(err))
+			}
+			recorded = err == nil
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"job_id": req.JobID,
-			"status": "completed",
-			"result": result,
+			"job_id":          req.JobID,
+			"status":          "completed",
+			"result":          result,
+			"result_recorded": recorded,
 		})
 	}
 }
 
+// deParameters returns the parameters CONTROL compares between versions of a
+// DE result, including the reference index the genes were annotated with.
+func deParameters(logger *zap.Logger, refManager *reference.Manager, result *models.DifferentialExpressionResult) map[string]any {
+	params := map[string]any{
+		"method":           result.Method,
+		"pvalue_threshold": result.PValueThreshold,
+		"log2fc_threshold": result.Log2FCThreshold,
+	}
+	if result.Organism == "" {
+		return params
+	}
+
+	params["organism"] = result.Organism
+	indexFile, version, err := refManager.IndexVersion(result.Organism)
+	if err != nil {
+		logger.Warn("reference version unknown", zap.String("organism", result.Organism), zap.Error(err))
+		return params
+	}
+	params["index_file"] = indexFile
+	params["reference_version"] = version
+	return params
+}
+
 func getString(m map[string]any, key string) string {
 	if v, ok := m[key].(string); ok {
 		return v
@@ -527,6 +621,94 @@ func handleGenerateMatrix(logger *zap.Logger, matrixGen *quantify.MatrixGenerato
 	}
 }
 
+// External abundance import handler
+
+type ImportAbundanceRequest struct {
+	Samples       []quantify.ExternalAbundance `json:"samples" binding:"required,min=1"`
+	OutputDir     string                       `json:"output_dir"`
+	StripVersions bool                         `json:"strip_versions"`
+	ReadLength    int                          `json:"read_length" binding:"omitempty,min=1"` // StringTie counts, default 75
+	// When both are set the import is recorded as a result in CONTROL
+	ExperimentID string `json:"experiment_id"`
+	JobID        string `json:"job_id"`
+}
+
+func handleImportAbundance(logger *zap.Logger, matrixGen *quantify.MatrixGenerator, cfg *config.Config, controlClient *control.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ImportAbundanceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		seen := make(map[string]bool)
+		for i, sample := range req.Samples {
+			if sample.SampleID == "" || sample.Path == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "each sample needs sample_id and path"})
+				return
+			}
+			if seen[sample.SampleID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate sample_id: " + sample.SampleID})
+				return
+			}
+			seen[sample.SampleID] = true
+
+			format, err := quantify.ParseAbundanceFormat(string(sample.Format))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			req.Samples[i].Format = format
+		}
+
+		outputDir := req.OutputDir
+		if outputDir == "" {
+			outputDir = filepath.Join(cfg.Directories.Results, "imports", uuid.New().String())
+		}
+
+		summary, err := matrixGen.ImportAbundanceFiles(req.Samples, quantify.ImportOptions{
+			TPMFile:       filepath.Join(outputDir, "tpm_matrix.tsv"),
+			CountsFile:    filepath.Join(outputDir, "counts_matrix.tsv"),
+			StripVersions: req.StripVersions,
+			ReadLength:    req.ReadLength,
+		})
+		if err != nil {
+			logger.Error("abundance import failed", zap.Error(err))
+			status := http.StatusInternalServerError
+			if errors.Is(err, quantify.ErrInvalidAbundance) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		recorded := false
+		if req.ExperimentID != "" && req.JobID != "" {
+			data, err := control.ResultData(summary)
+			if err == nil {
+				err = controlClient.RecordResult(c.Request.Context(), control.Result{
+					ExperimentID: req.ExperimentID,
+					JobID:        req.JobID,
+					Type:         control.ResultTypeAbundanceImport,
+					Data:         data,
+					FilePath:     summary.TPMFile,
+				})
+			}
+			if err != nil {
+				logger.Warn("failed to record import in CONTROL", zap.String("job_id", req.JobID), zap.Error(err))
+			}
+			recorded = err == nil
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":          "completed",
+			"output_dir":      outputDir,
+			"summary":         summary,
+			"result_recorded": recorded,
+		})
+	}
+}
+
 // Index building handler
 
 type BuildIndexRequest struct {
@@ -620,6 +802,106 @@ func handleAddCustomOrganism(logger *zap.Logger, refManager *reference.Manager)
 	}
 }
 
+// handleUploadReference accepts a transcriptome FASTA (and optional GTF) as
+// multipart form data, validates it and builds its index in the background.
+func handleUploadReference(buildCtx context.Context, logger *zap.Logger, refManager *reference.Manager, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ref := reference.CustomReference{
+			Name:           c.PostForm("name"),
+			ScientificName: c.PostForm("scientific_name"),
+			TaxID:          c.PostForm("tax_id"),
+		}
+		if ref.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		if _, exists := refManager.GetOrganism(ref.Name); exists {
+			c.JSON(http.StatusConflict, gin.H{"error": "organism already exists: " + reference.NormalizeOrganismName(ref.Name)})
+			return
+		}
+
+		fasta, err := c.FormFile("fasta")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fasta file is required"})
+			return
+		}
+
+		uploadDir, err := os.MkdirTemp(cfg.Directories.Temp, "reference_upload_")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ref.UploadDir = uploadDir
+		ref.FASTAPath = filepath.Join(uploadDir, "transcripts"+uploadExt(fasta.Filename))
+		if err := c.SaveUploadedFile(fasta, ref.FASTAPath); err != nil {
+			os.RemoveAll(uploadDir)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if gtf, err := c.FormFile("gtf"); err == nil {
+			ref.GTFPath = filepath.Join(uploadDir, "annotation"+uploadExt(gtf.Filename))
+			if err := c.SaveUploadedFile(gtf, ref.GTFPath); err != nil {
+				os.RemoveAll(uploadDir)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		validation, err := refManager.ValidateReference(ref)
+		if err != nil {
+			os.RemoveAll(uploadDir)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !validation.Valid() {
+			os.RemoveAll(uploadDir)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "reference validation failed",
+				"validation": validation,
+			})
+			return
+		}
+
+		build, err := refManager.StartCustomBuild(buildCtx, ref, validation)
+		if err != nil {
+			os.RemoveAll(uploadDir)
+			if errors.Is(err, reference.ErrOrganismExists) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			logger.Error("failed to start index build", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "building",
+			"build":   build,
+			"message": "Index build started. Check /api/v1/references/builds/" + build.ID + " for progress.",
+		})
+	}
+}
+
+// uploadExt keeps the .gz suffix of an uploaded file name.
+func uploadExt(filename string) string {
+	if strings.HasSuffix(strings.ToLower(filename), ".gz") {
+		return ".gz"
+	}
+	return ""
+}
+
+func handleGetReferenceBuild(refManager *reference.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		build, ok := refManager.GetBuild(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "build not found"})
+			return
+		}
+		c.JSON(http.StatusOK, build)
+	}
+}
+
 // Pipeline handlers
 
 func handleStartPipeline(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
@@ -631,6 +913,9 @@ func handleStartPipeline(logger *zap.Logger, orchestrator *pipeline.Orchestrator
 			Trailing      int    `json:"trailing"`
 			SlidingWindow string `json:"sliding_window"`
 			MinLen        int    `json:"min_len"`
+			CropLength    int    `json:"crop_length"`
+			KeepShorter   bool   `json:"keep_shorter"`
+			Stream        bool   `json:"stream"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -644,6 +929,9 @@ func handleStartPipeline(logger *zap.Logger, orchestrator *pipeline.Orchestrator
 			Trailing:      req.Trailing,
 			SlidingWindow: req.SlidingWindow,
 			MinLen:        req.MinLen,
+			CropLength:    req.CropLength,
+			KeepShorter:   req.KeepShorter,
+			Stream:        req.Stream,
 		}
 
 		jobID, err := orchestrator.StartPipeline(c.Request.Context(), input)
@@ -661,6 +949,108 @@ func handleStartPipeline(logger *zap.Logger, orchestrator *pipeline.Orchestrator
 	}
 }
 
+func handleStartSweep(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Accession     string              `json:"accession" binding:"required"`
+			Organism      string              `json:"organism"`
+			Leading       int                 `json:"leading"`
+			Trailing      int                 `json:"trailing"`
+			SlidingWindow string              `json:"sliding_window"`
+			MinLen        int                 `json:"min_len"`
+			CropLength    int                 `json:"crop_length"`
+			Grid          *pipeline.SweepGrid `json:"grid" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		input := pipeline.PipelineInput{
+			Accession:     req.Accession,
+			Organism:      req.Organism,
+			Leading:       req.Leading,
+			Trailing:      req.Trailing,
+			SlidingWindow: req.SlidingWindow,
+			MinLen:        req.MinLen,
+			CropLength:    req.CropLength,
+			Sweep:         req.Grid,
+		}
+
+		jobID, err := orchestrator.StartSweep(c.Request.Context(), input)
+		if err != nil {
+			logger.Warn("failed to start sweep", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "started",
+			"job_id":  jobID,
+			"message": "Parameter sweep started. Check /api/v1/pipeline/jobs/" + jobID + " for results.",
+		})
+	}
+}
+
+func handleStartSaturation(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Accession     string `json:"accession"`
+			Organism      string `json:"organism"`
+			Leading       int    `json:"leading"`
+			Trailing      int    `json:"trailing"`
+			SlidingWindow string `json:"sliding_window"`
+			MinLen        int    `json:"min_len"`
+			pipeline.SaturationOptions
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		input := pipeline.PipelineInput{
+			Accession:     req.Accession,
+			Organism:      req.Organism,
+			Leading:       req.Leading,
+			Trailing:      req.Trailing,
+			SlidingWindow: req.SlidingWindow,
+			MinLen:        req.MinLen,
+			Saturation:    &req.SaturationOptions,
+		}
+
+		jobID, err := orchestrator.StartSaturation(c.Request.Context(), input)
+		if err != nil {
+			logger.Warn("failed to start saturation analysis", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "started",
+			"job_id":  jobID,
+			"message": "Saturation analysis started. Check /api/v1/pipeline/jobs/" + jobID + " for results.",
+		})
+	}
+}
+
+// serviceURLs returns the configured replica URLs of a service, falling back
+// to the environment variable or its default when the configuration did not
+// provide any (e.g. when the config file failed to load).
+func serviceURLs(configured, envKey, defaultURL string) []string {
+	if urls := discovery.ParseURLs(configured); len(urls) > 0 {
+		return urls
+	}
+	return discovery.ParseURLs(getEnvOrDefault(envKey, defaultURL))
+}
+
+func handleListServices(registry *discovery.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"services": registry.Services(),
+		})
+	}
+}
+
 func handleListPipelineJobs(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		jobs := orchestrator.ListJobs()
@@ -708,6 +1098,85 @@ func handleCancelPipelineJob(logger *zap.Logger, orchestrator *pipeline.Orchestr
 	}
 }
 
+// BulkPipelineJobsRequest selects the pipeline jobs affected by a bulk operation.
+type BulkPipelineJobsRequest struct {
+	Status        []pipeline.JobStatus `json:"status"`
+	Organism      string               `json:"organism"`
+	CreatedAfter  *time.Time           `json:"created_after"`
+	CreatedBefore *time.Time           `json:"created_before"`
+}
+
+func bindPipelineJobFilter(c *gin.Context) (pipeline.JobFilter, bool) {
+	var req BulkPipelineJobsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return pipeline.JobFilter{}, false
+	}
+
+	filter := pipeline.JobFilter{
+		Statuses:      req.Status,
+		Organism:      req.Organism,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+	}
+	if filter.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one filter is required"})
+		return filter, false
+	}
+	return filter, true
+}
+
+func handleBulkCancelPipelineJobs(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindPipelineJobFilter(c)
+		if !ok {
+			return
+		}
+
+		ids := orchestrator.CancelJobs(filter)
+		logger.Info("pipeline jobs cancelled in bulk", zap.Int("count", len(ids)))
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "cancelled",
+			"affected": len(ids),
+			"job_ids":  ids,
+		})
+	}
+}
+
+func handleBulkRetryPipelineJobs(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindPipelineJobFilter(c)
+		if !ok {
+			return
+		}
+
+		ids := orchestrator.RetryJobs(filter)
+		logger.Info("pipeline jobs retried in bulk", zap.Int("count", len(ids)))
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":   "started",
+			"affected": len(ids),
+			"job_ids":  ids,
+		})
+	}
+}
+
+func handleBulkDeletePipelineJobs(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindPipelineJobFilter(c)
+		if !ok {
+			return
+		}
+
+		ids := orchestrator.DeleteJobs(filter)
+		logger.Info("pipeline jobs deleted in bulk", zap.Int("count", len(ids)))
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "deleted",
+			"affected": len(ids),
+			"job_ids":  ids,
+		})
+	}
+}
+
 func handlePipelineProgress(logger *zap.Logger, orchestrator *pipeline.Orchestrator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		jobID := c.Param("id")
@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/google/uuid v1.5.0
+	github.com/guidiju-50/pandora/SHARED v0.0.0
 	github.com/spf13/viper v1.18.2
 	go.uber.org/zap v1.26.0
 )
@@ -49,3 +50,7 @@ require (
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+// SHARED holds packages used by several modules; see docker-compose.yml for
+// the build context that makes it available.
+replace github.com/guidiju-50/pandora/SHARED => ../SHARED
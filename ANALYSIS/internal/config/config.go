@@ -14,6 +14,8 @@ type Config struct {
 	R             RConfig             `mapstructure:"r"`
 	Analysis      AnalysisConfig      `mapstructure:"analysis"`
 	Control       ControlAPIConfig    `mapstructure:"control"`
+	Processing    ProcessingAPIConfig `mapstructure:"processing"`
+	Discovery     DiscoveryConfig     `mapstructure:"discovery"`
 	Directories   DirectoriesConfig   `mapstructure:"directories"`
 }
 
@@ -68,11 +70,22 @@ type AnalysisConfig struct {
 
 // ControlAPIConfig holds CONTROL module API configuration.
 type ControlAPIConfig struct {
-	URL     string        `mapstructure:"url"`
+	URL     string        `mapstructure:"url"` // Comma-separated replica URLs
 	Timeout time.Duration `mapstructure:"timeout"`
 	APIKey  string        `mapstructure:"api_key"`
 }
 
+// ProcessingAPIConfig holds PROCESSING module API configuration.
+type ProcessingAPIConfig struct {
+	URL string `mapstructure:"url"` // Comma-separated replica URLs
+}
+
+// DiscoveryConfig holds service discovery configuration.
+type DiscoveryConfig struct {
+	HealthInterval time.Duration `mapstructure:"health_interval"`
+	HealthTimeout  time.Duration `mapstructure:"health_timeout"`
+}
+
 // DirectoriesConfig holds directory paths.
 type DirectoriesConfig struct {
 	Data    string `mapstructure:"data"`
@@ -130,6 +143,13 @@ func setDefaults() {
 	viper.SetDefault("control.url", "http://localhost:8080")
 	viper.SetDefault("control.timeout", "30s")
 
+	// Processing API
+	viper.SetDefault("processing.url", "http://processing:8081")
+
+	// Service discovery
+	viper.SetDefault("discovery.health_interval", "15s")
+	viper.SetDefault("discovery.health_timeout", "3s")
+
 	// Directories
 	viper.SetDefault("directories.data", "/data/analysis")
 	viper.SetDefault("directories.results", "/data/results")
@@ -144,4 +164,7 @@ func bindEnvVariables() {
 	viper.BindEnv("r.libs_path", "R_LIBS_USER")
 	viper.BindEnv("control.url", "CONTROL_API_URL")
 	viper.BindEnv("control.api_key", "CONTROL_API_KEY")
+	viper.BindEnv("processing.url", "PROCESSING_URL")
+	viper.BindEnv("discovery.health_interval", "SERVICE_HEALTH_INTERVAL")
+	viper.BindEnv("discovery.health_timeout", "SERVICE_HEALTH_TIMEOUT")
 }
@@ -0,0 +1,103 @@
+// Package control provides a client for the CONTROL module's internal API.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/config"
+	"github.com/guidiju-50/pandora/SHARED/discovery"
+	"go.uber.org/zap"
+)
+
+// Result types recorded in CONTROL.
+const (
+	ResultTypeDifferential    = "differential_expression"
+	ResultTypeAbundanceImport = "abundance_import"
+)
+
+// Result is an analysis result submitted to CONTROL. CONTROL stores it as
+// the next version of its experiment/type/comparison series.
+type Result struct {
+	ExperimentID string         `json:"experiment_id"`
+	JobID        string         `json:"job_id"`
+	Type         string         `json:"type"`
+	Comparison   string         `json:"comparison,omitempty"`
+	Parameters   map[string]any `json:"parameters,omitempty"`
+	Data         map[string]any `json:"data"`
+	FilePath     string         `json:"file_path,omitempty"`
+}
+
+// Client sends results to the CONTROL module.
+type Client struct {
+	config   config.ControlAPIConfig
+	registry *discovery.Registry
+	client   *http.Client
+	logger   *zap.Logger
+}
+
+// NewClient creates a new CONTROL client.
+func NewClient(cfg config.ControlAPIConfig, registry *discovery.Registry, logger *zap.Logger) *Client {
+	return &Client{
+		config:   cfg,
+		registry: registry,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+		logger: logger,
+	}
+}
+
+// RecordResult stores a result in CONTROL. A version conflict (another
+// version of the same series stored concurrently) is retried once.
+func (c *Client) RecordResult(ctx context.Context, result Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshaling result: %w", err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.registry.Do(ctx, c.client, discovery.ServiceControl, http.MethodPost, "/api/v1/internal/results", data, header)
+		if err != nil {
+			return fmt.Errorf("sending result: %w", err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusConflict && attempt == 0 {
+			continue
+		}
+		if resp.StatusCode != http.StatusCreated {
+			return fmt.Errorf("CONTROL returned status %d: %s", resp.StatusCode, body)
+		}
+
+		c.logger.Info("result recorded in CONTROL",
+			zap.String("job_id", result.JobID),
+			zap.String("type", result.Type),
+			zap.String("comparison", result.Comparison),
+		)
+		return nil
+	}
+}
+
+// ResultData converts a result value to the JSON object stored by CONTROL.
+func ResultData(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -44,6 +44,7 @@ type DifferentialExpressionResult struct {
 	ID              uuid.UUID       `json:"id"`
 	ExperimentID    uuid.UUID       `json:"experiment_id"`
 	Comparison      string          `json:"comparison"` // e.g., "treatment_vs_control"
+	Organism        string          `json:"organism,omitempty"`
 	Method          string          `json:"method"`     // deseq2, edger, limma
 	Genes           []DEGene        `json:"genes"`
 	SignificantUp   int             `json:"significant_up"`
@@ -58,6 +59,7 @@ type DifferentialExpressionResult struct {
 type DEGene struct {
 	GeneID      string  `json:"gene_id"`
 	GeneName    string  `json:"gene_name"`
+	Description string  `json:"description,omitempty"`
 	BaseMean    float64 `json:"base_mean"`
 	Log2FC      float64 `json:"log2_fold_change"`
 	LFCSError   float64 `json:"lfcse,omitempty"`
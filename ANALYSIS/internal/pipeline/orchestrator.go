@@ -15,6 +15,7 @@ import (
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/models"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/quantify"
 	"github.com/guidiju-50/pandora/ANALYSIS/internal/reference"
+	"github.com/guidiju-50/pandora/SHARED/discovery"
 	"go.uber.org/zap"
 )
 
@@ -29,6 +30,9 @@ const (
 	StatusCancelled  JobStatus = "cancelled"
 )
 
+// defaultOrganism is used when a job does not name an organism.
+const defaultOrganism = "helicoverpa_armigera"
+
 // PipelineJob represents a complete pipeline job.
 type PipelineJob struct {
 	ID           string                 `json:"id"`
@@ -53,6 +57,15 @@ type PipelineInput struct {
 	Trailing     int    `json:"trailing"`
 	SlidingWindow string `json:"sliding_window"`
 	MinLen       int    `json:"min_len"`
+	// Read length harmonization (0 disables cropping)
+	CropLength   int    `json:"crop_length"`
+	KeepShorter  bool   `json:"keep_shorter"`
+	// Stream ENA reads straight into Trimmomatic (no raw FASTQ on disk)
+	Stream       bool   `json:"stream"`
+	// Parameter grid; when set the job runs as a parameter sweep
+	Sweep        *SweepGrid `json:"sweep,omitempty"`
+	// When set the job runs as a library saturation analysis
+	Saturation   *SaturationOptions `json:"saturation,omitempty"`
 }
 
 // PipelineOutput contains the results of the pipeline.
@@ -65,23 +78,25 @@ type PipelineOutput struct {
 	MappedReads      int64                   `json:"mapped_reads"`
 	MappingRate      float64                 `json:"mapping_rate"`
 	TranscriptCount  int                     `json:"transcript_count"`
+	Sweep            *SweepSummary           `json:"sweep,omitempty"`
+	Saturation       *SaturationSummary      `json:"saturation,omitempty"`
 }
 
 // Orchestrator coordinates the complete pipeline.
 type Orchestrator struct {
-	processingURL    string
+	registry         *discovery.Registry
 	referenceManager *reference.Manager
 	kallisto         *quantify.Kallisto
 	matrixGen        *quantify.MatrixGenerator
 	jobs             sync.Map
-	cancelFuncs      sync.Map // map[string]context.CancelFunc
+	cancelFuncs      sync.Map // map[string]context.CancelFunc; present while a runner is alive
 	outputDir        string
 	logger           *zap.Logger
 }
 
 // NewOrchestrator creates a new pipeline orchestrator.
 func NewOrchestrator(
-	processingURL string,
+	registry *discovery.Registry,
 	refManager *reference.Manager,
 	kallisto *quantify.Kallisto,
 	matrixGen *quantify.MatrixGenerator,
@@ -89,7 +104,7 @@ func NewOrchestrator(
 	logger *zap.Logger,
 ) *Orchestrator {
 	return &Orchestrator{
-		processingURL:    processingURL,
+		registry:         registry,
 		referenceManager: refManager,
 		kallisto:         kallisto,
 		matrixGen:        matrixGen,
@@ -115,17 +130,35 @@ func (o *Orchestrator) StartPipeline(ctx context.Context, input PipelineInput) (
 	o.jobs.Store(jobID, job)
 	o.logger.Info("pipeline job created", zap.String("job_id", jobID), zap.String("accession", input.Accession))
 
+	o.launch(job)
+
+	return jobID, nil
+}
+
+// launch runs a pipeline job asynchronously with a cancellable context.
+func (o *Orchestrator) launch(job *PipelineJob) {
 	// Create cancellable context and store cancel function
 	pipelineCtx, cancel := context.WithCancel(context.Background())
-	o.cancelFuncs.Store(jobID, cancel)
+	o.cancelFuncs.Store(job.ID, cancel)
+	o.run(pipelineCtx, cancel, job)
+}
 
-	// Run pipeline asynchronously
+// run starts the runner of a job whose cancel function is already
+// registered. The registration is removed only once the runner has exited.
+func (o *Orchestrator) run(pipelineCtx context.Context, cancel context.CancelFunc, job *PipelineJob) {
 	go func() {
-		defer o.cancelFuncs.Delete(jobID)
+		defer o.cancelFuncs.Delete(job.ID)
+		defer cancel()
+		if job.Input.Sweep != nil {
+			o.runSweep(pipelineCtx, job)
+			return
+		}
+		if job.Input.Saturation != nil {
+			o.runSaturation(pipelineCtx, job)
+			return
+		}
 		o.runPipeline(pipelineCtx, job)
 	}()
-
-	return jobID, nil
 }
 
 // GetJob returns a pipeline job by ID.
@@ -160,12 +193,11 @@ func (o *Orchestrator) CancelJob(jobID string) bool {
 		return false
 	}
 
-	// Call cancel function if exists
+	// Call cancel function if exists; the runner unregisters it when it exits
 	if cancelValue, ok := o.cancelFuncs.Load(jobID); ok {
 		if cancel, ok := cancelValue.(context.CancelFunc); ok {
 			cancel()
 		}
-		o.cancelFuncs.Delete(jobID)
 	}
 
 	// Update job status
@@ -180,6 +212,138 @@ func (o *Orchestrator) CancelJob(jobID string) bool {
 	return true
 }
 
+// JobFilter selects pipeline jobs for bulk operations. Empty fields match every job.
+type JobFilter struct {
+	Statuses      []JobStatus
+	Organism      string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// IsEmpty reports whether the filter has no criteria and would match every job.
+func (f JobFilter) IsEmpty() bool {
+	return len(f.Statuses) == 0 && f.Organism == "" && f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+// matches reports whether a job satisfies the filter.
+func (f JobFilter) matches(job *PipelineJob) bool {
+	if f.Organism != "" && !strings.EqualFold(job.Input.Organism, f.Organism) {
+		return false
+	}
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, s := range f.Statuses {
+			if job.Status == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.CreatedAfter != nil && job.CreatedAt.Before(*f.CreatedAfter) {
+		return false
+	}
+	if f.CreatedBefore != nil && !job.CreatedAt.Before(*f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// filterJobs returns all jobs matching the filter. An empty filter matches
+// nothing so bulk operations never touch every job by accident.
+func (o *Orchestrator) filterJobs(filter JobFilter) []*PipelineJob {
+	var jobs []*PipelineJob
+	if filter.IsEmpty() {
+		return jobs
+	}
+	o.jobs.Range(func(key, value interface{}) bool {
+		if job := value.(*PipelineJob); filter.matches(job) {
+			jobs = append(jobs, job)
+		}
+		return true
+	})
+	return jobs
+}
+
+// CancelJobs cancels all pending or running jobs matching the filter and
+// returns the IDs of the cancelled jobs.
+func (o *Orchestrator) CancelJobs(filter JobFilter) []string {
+	cancelled := make([]string, 0)
+	for _, job := range o.filterJobs(filter) {
+		if o.CancelJob(job.ID) {
+			cancelled = append(cancelled, job.ID)
+		}
+	}
+	return cancelled
+}
+
+// RetryJob restarts a failed or cancelled pipeline job with its original
+// input. A job whose previous runner has not exited yet (e.g. a cancelled
+// run still unwinding) is not retried, so two runs never share a job.
+func (o *Orchestrator) RetryJob(jobID string) bool {
+	job, ok := o.GetJob(jobID)
+	if !ok {
+		return false
+	}
+
+	// Reserve the job; fails while the previous runner is alive or another retry won
+	pipelineCtx, cancel := context.WithCancel(context.Background())
+	if _, running := o.cancelFuncs.LoadOrStore(jobID, cancel); running {
+		cancel()
+		return false
+	}
+
+	if job.Status != StatusFailed && job.Status != StatusCancelled {
+		o.cancelFuncs.Delete(jobID)
+		cancel()
+		return false
+	}
+
+	// Start from a fresh job so readers of the previous attempt never see it change
+	retry := &PipelineJob{
+		ID:        job.ID,
+		Status:    StatusPending,
+		Stage:     "Initializing",
+		Message:   "Pipeline job queued for retry",
+		Input:     job.Input,
+		CreatedAt: job.CreatedAt,
+	}
+	o.jobs.Store(jobID, retry)
+
+	o.logger.Info("pipeline job retried", zap.String("job_id", jobID))
+	o.run(pipelineCtx, cancel, retry)
+	return true
+}
+
+// RetryJobs restarts all failed or cancelled jobs matching the filter and
+// returns the IDs of the retried jobs.
+func (o *Orchestrator) RetryJobs(filter JobFilter) []string {
+	retried := make([]string, 0)
+	for _, job := range o.filterJobs(filter) {
+		if o.RetryJob(job.ID) {
+			retried = append(retried, job.ID)
+		}
+	}
+	return retried
+}
+
+// DeleteJobs removes all completed, failed or cancelled jobs matching the
+// filter and returns the IDs of the deleted jobs.
+func (o *Orchestrator) DeleteJobs(filter JobFilter) []string {
+	deleted := make([]string, 0)
+	for _, job := range o.filterJobs(filter) {
+		// Never delete jobs that are still queued or executing
+		if job.Status == StatusPending || job.Status == StatusRunning {
+			continue
+		}
+		o.jobs.Delete(job.ID)
+		deleted = append(deleted, job.ID)
+	}
+	return deleted
+}
+
 // runPipeline executes the complete pipeline.
 func (o *Orchestrator) runPipeline(ctx context.Context, job *PipelineJob) {
 	startTime := time.Now()
@@ -266,10 +430,7 @@ func (o *Orchestrator) runPipeline(ctx context.Context, job *PipelineJob) {
 
 // ensureIndex ensures the Kallisto index is available.
 func (o *Orchestrator) ensureIndex(ctx context.Context, job *PipelineJob) (string, error) {
-	organism := job.Input.Organism
-	if organism == "" {
-		organism = "helicoverpa_armigera" // Default organism
-	}
+	organism := jobOrganism(job)
 
 	// Ensure index is available
 	err := o.referenceManager.EnsureIndex(ctx, organism, func(stage string, progress int) {
@@ -285,32 +446,41 @@ func (o *Orchestrator) ensureIndex(ctx context.Context, job *PipelineJob) (strin
 	return o.referenceManager.GetIndexPath(organism)
 }
 
+// jobOrganism returns the organism of a job, falling back to the default one.
+func jobOrganism(job *PipelineJob) string {
+	if job.Input.Organism == "" {
+		return defaultOrganism
+	}
+	return job.Input.Organism
+}
+
 // downloadAndTrim calls the PROCESSING module to download and trim.
 func (o *Orchestrator) downloadAndTrim(ctx context.Context, job *PipelineJob) ([]string, []string, error) {
-	// Call PROCESSING API
-	url := fmt.Sprintf("%s/api/v1/jobs/full-pipeline", o.processingURL)
-
 	// Build request body
 	reqBody := fmt.Sprintf(`{
 		"accession": "%s",
 		"leading": %d,
 		"trailing": %d,
 		"sliding_window": "%s",
-		"min_len": %d
+		"min_len": %d,
+		"crop_length": %d,
+		"keep_shorter": %t,
+		"stream": %t
 	}`, job.Input.Accession,
 		getOrDefault(job.Input.Leading, 3),
 		getOrDefault(job.Input.Trailing, 3),
 		getOrDefaultStr(job.Input.SlidingWindow, "4:15"),
-		getOrDefault(job.Input.MinLen, 36))
+		getOrDefault(job.Input.MinLen, 36),
+		job.Input.CropLength,
+		job.Input.KeepShorter,
+		job.Input.Stream)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(reqBody))
-	if err != nil {
-		return nil, nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
+	// Call PROCESSING API, failing over to another instance if needed
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
 
 	client := &http.Client{Timeout: 30 * time.Minute}
-	resp, err := client.Do(req)
+	resp, err := o.registry.Do(ctx, client, discovery.ServiceProcessing, http.MethodPost, "/api/v1/jobs/full-pipeline", []byte(reqBody), header)
 	if err != nil {
 		return nil, nil, fmt.Errorf("calling PROCESSING: %w", err)
 	}
@@ -338,8 +508,11 @@ func (o *Orchestrator) downloadAndTrim(ctx context.Context, job *PipelineJob) ([
 		case <-time.After(pollInterval):
 		}
 
-		// Check for trimmed files
+		// Check for trimmed files (cropped reads replace them when harmonization is enabled)
 		trimmedDir := filepath.Join(outputDir, "trimmed")
+		if job.Input.CropLength > 0 {
+			trimmedDir = filepath.Join(outputDir, "cropped")
+		}
 		if files, err := filepath.Glob(filepath.Join(trimmedDir, "*.fastq*")); err == nil && len(files) > 0 {
 			trimmedFiles = files
 		}
@@ -380,19 +553,7 @@ func (o *Orchestrator) runKallisto(ctx context.Context, job *PipelineJob, indexP
 		return "", nil, err
 	}
 
-	// Find paired files
-	var reads1, reads2 string
-	for _, f := range trimmedFiles {
-		if strings.Contains(f, "_1") || strings.Contains(f, "_paired_1") || strings.Contains(f, "forward") {
-			reads1 = f
-		} else if strings.Contains(f, "_2") || strings.Contains(f, "_paired_2") || strings.Contains(f, "reverse") {
-			reads2 = f
-		}
-	}
-
-	if reads1 == "" && len(trimmedFiles) > 0 {
-		reads1 = trimmedFiles[0]
-	}
+	reads1, reads2 := pairReadFiles(trimmedFiles)
 
 	opts := quantify.QuantifyOptions{
 		SampleID:  accession,
@@ -411,6 +572,24 @@ func (o *Orchestrator) runKallisto(ctx context.Context, job *PipelineJob, indexP
 	return kallistoDir, result, nil
 }
 
+// pairReadFiles splits FASTQ files into forward and reverse reads.
+func pairReadFiles(files []string) (string, string) {
+	var reads1, reads2 string
+	for _, f := range files {
+		if strings.Contains(f, "_1") || strings.Contains(f, "_paired_1") || strings.Contains(f, "forward") {
+			reads1 = f
+		} else if strings.Contains(f, "_2") || strings.Contains(f, "_paired_2") || strings.Contains(f, "reverse") {
+			reads2 = f
+		}
+	}
+
+	if reads1 == "" && len(files) > 0 {
+		reads1 = files[0]
+	}
+
+	return reads1, reads2
+}
+
 // generateMatrix generates the TPM matrix file.
 func (o *Orchestrator) generateMatrix(ctx context.Context, job *PipelineJob, kallistoDir string) (string, error) {
 	accession := job.Input.Accession
@@ -433,8 +612,11 @@ func (o *Orchestrator) updateProgress(job *PipelineJob, progress int, stage, mes
 	o.logger.Debug("pipeline progress", zap.String("job_id", job.ID), zap.Int("progress", progress), zap.String("stage", stage))
 }
 
-// failJob marks a job as failed.
+// failJob marks a job as failed. Cancelled jobs keep their status.
 func (o *Orchestrator) failJob(job *PipelineJob, message string) {
+	if job.Status == StatusCancelled {
+		return
+	}
 	job.Status = StatusFailed
 	job.Error = message
 	now := time.Now()
@@ -443,6 +625,21 @@ func (o *Orchestrator) failJob(job *PipelineJob, message string) {
 	o.logger.Error("pipeline failed", zap.String("job_id", job.ID), zap.String("error", message))
 }
 
+// cancelJob marks a job whose context ended as cancelled. Jobs cancelled
+// through CancelJob keep their status and message.
+func (o *Orchestrator) cancelJob(job *PipelineJob) {
+	if job.Status == StatusCancelled {
+		return
+	}
+	now := time.Now()
+	job.Status = StatusCancelled
+	job.Stage = "Cancelled"
+	job.Message = "Job cancelled"
+	job.CompletedAt = &now
+	o.jobs.Store(job.ID, job)
+	o.logger.Info("pipeline job cancelled", zap.String("job_id", job.ID))
+}
+
 func getOrDefault(val, def int) int {
 	if val <= 0 {
 		return def
@@ -0,0 +1,419 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/models"
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/quantify"
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/reference"
+	"go.uber.org/zap"
+)
+
+// maxSaturationDepths bounds the number of subsampling depths per sample.
+const maxSaturationDepths = 12
+
+// defaultSaturationFractions are the sequencing depths sampled when none are given.
+var defaultSaturationFractions = []float64{0.1, 0.25, 0.5, 0.75, 1}
+
+// SaturationOptions configures a library complexity and saturation analysis.
+type SaturationOptions struct {
+	// Samples to analyze; when empty the job accession is downloaded and trimmed
+	Samples   []SaturationSample `json:"samples,omitempty"`
+	Fractions []float64          `json:"fractions,omitempty"`  // Read fractions in (0, 1]; the full library is always included
+	MinCounts float64            `json:"min_counts,omitempty"` // Estimated counts for a gene to count as detected (default 1)
+	// Relative gain (%) in detected genes over the last depth step below
+	// which a library counts as saturated (default 5)
+	GainThreshold float64 `json:"gain_threshold,omitempty"`
+	Seed          int64   `json:"seed,omitempty"`
+}
+
+// SaturationSample is a trimmed FASTQ library.
+type SaturationSample struct {
+	SampleID string `json:"sample_id"`
+	Reads1   string `json:"reads1"`
+	Reads2   string `json:"reads2,omitempty"`
+}
+
+// SaturationPoint holds the genes detected at one sequencing depth.
+type SaturationPoint struct {
+	Fraction            float64 `json:"fraction"`
+	Reads               int64   `json:"reads"`
+	MappingRate         float64 `json:"mapping_rate"`
+	DetectedGenes       int     `json:"detected_genes"`
+	DetectedTranscripts int     `json:"detected_transcripts"`
+}
+
+// SaturationCurve is the saturation curve of one sample.
+type SaturationCurve struct {
+	SampleID      string            `json:"sample_id"`
+	TotalReads    int64             `json:"total_reads"`
+	Points        []SaturationPoint `json:"points"`
+	DetectedGenes int               `json:"detected_genes"`
+	// Detected genes gained over the last depth step, relative and per million reads
+	LastStepGain    float64 `json:"last_step_gain"`
+	GenesPerMillion float64 `json:"genes_per_million"`
+	Saturated       bool    `json:"saturated"`
+	Recommendation  string  `json:"recommendation,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// SaturationSummary summarizes the saturation analysis of all samples.
+// Level is "gene" when transcripts could be collapsed with the reference
+// annotation, otherwise "transcript".
+type SaturationSummary struct {
+	Level          string            `json:"level"`
+	MinCounts      float64           `json:"min_counts"`
+	GainThreshold  float64           `json:"gain_threshold"`
+	Curves         []SaturationCurve `json:"curves"`
+	ShallowSamples []string          `json:"shallow_samples"`
+	CurveFile      string            `json:"curve_file,omitempty"`
+}
+
+// StartSaturation validates the options and starts a saturation analysis job.
+func (o *Orchestrator) StartSaturation(ctx context.Context, input PipelineInput) (string, error) {
+	opts := input.Saturation
+	if opts == nil {
+		return "", fmt.Errorf("saturation options are required")
+	}
+	if len(opts.Samples) == 0 && input.Accession == "" {
+		return "", fmt.Errorf("either samples or accession is required")
+	}
+
+	seen := make(map[string]bool)
+	for i, sample := range opts.Samples {
+		if sample.SampleID == "" || sample.Reads1 == "" {
+			return "", fmt.Errorf("samples[%d]: sample_id and reads1 are required", i)
+		}
+		if strings.ContainsAny(sample.SampleID, `/\`) {
+			return "", fmt.Errorf("samples[%d]: invalid sample_id %q", i, sample.SampleID)
+		}
+		if seen[sample.SampleID] {
+			return "", fmt.Errorf("samples[%d]: duplicate sample_id %q", i, sample.SampleID)
+		}
+		seen[sample.SampleID] = true
+	}
+
+	for _, f := range opts.Fractions {
+		if f <= 0 || f > 1 {
+			return "", fmt.Errorf("fractions must be in (0, 1], got %g", f)
+		}
+	}
+	if err := quantify.CheckFractionLabels(saturationFractions(opts.Fractions)); err != nil {
+		return "", err
+	}
+	if n := len(saturationFractions(opts.Fractions)); n > maxSaturationDepths {
+		return "", fmt.Errorf("saturation has %d depths, maximum is %d", n, maxSaturationDepths)
+	} else if n < 2 {
+		return "", fmt.Errorf("at least one fraction below 1 is required")
+	}
+	if opts.MinCounts < 0 || opts.GainThreshold < 0 {
+		return "", fmt.Errorf("min_counts and gain_threshold must not be negative")
+	}
+
+	return o.StartPipeline(ctx, input)
+}
+
+// runSaturation subsamples each library at increasing depths, quantifies
+// every subsample and records how many genes are detected per depth.
+func (o *Orchestrator) runSaturation(ctx context.Context, job *PipelineJob) {
+	startTime := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &startTime
+	o.jobs.Store(job.ID, job)
+
+	defer func() {
+		if r := recover(); r != nil {
+			job.Status = StatusFailed
+			job.Error = fmt.Sprintf("saturation panicked: %v", r)
+			now := time.Now()
+			job.CompletedAt = &now
+			o.jobs.Store(job.ID, job)
+		}
+	}()
+
+	opts := *job.Input.Saturation
+	fractions := saturationFractions(opts.Fractions)
+	minCounts := opts.MinCounts
+	if minCounts <= 0 {
+		minCounts = 1
+	}
+	gainThreshold := opts.GainThreshold
+	if gainThreshold <= 0 {
+		gainThreshold = 5
+	}
+
+	// Stage 1: Ensure reference index (0-20%)
+	o.updateProgress(job, 5, "Preparing reference index", "Checking Kallisto index for "+job.Input.Organism)
+
+	indexPath, err := o.ensureIndex(ctx, job)
+	if err != nil {
+		o.failJob(job, "reference preparation failed: "+err.Error())
+		return
+	}
+
+	summary := &SaturationSummary{
+		Level:          "gene",
+		MinCounts:      minCounts,
+		GainThreshold:  gainThreshold,
+		ShallowSamples: make([]string, 0),
+	}
+	annotation, err := o.referenceManager.Annotation(ctx, jobOrganism(job))
+	if err != nil {
+		o.logger.Warn("no gene annotation, measuring saturation on transcripts", zap.Error(err))
+		summary.Level = "transcript"
+		annotation = nil
+	}
+
+	// Stage 2: Download and trim the accession when no samples are given (20-60%)
+	samples := opts.Samples
+	if len(samples) == 0 {
+		o.updateProgress(job, 25, "Starting download", "Requesting download from PROCESSING module")
+
+		_, trimmedFiles, err := o.downloadAndTrim(ctx, job)
+		if err != nil {
+			o.failJob(job, "download failed: "+err.Error())
+			return
+		}
+		reads1, reads2 := pairReadFiles(trimmedFiles)
+		if reads1 == "" {
+			reads1 = trimmedFiles[0]
+		}
+		samples = []SaturationSample{{SampleID: job.Input.Accession, Reads1: reads1, Reads2: reads2}}
+	}
+
+	// Stage 3: Subsample and quantify every sample (60-95%)
+	saturationDir := filepath.Join(o.outputDir, "saturation", job.ID)
+	total := len(samples) * len(fractions)
+	done := 0
+	completed := 0
+
+	for _, sample := range samples {
+		if ctx.Err() != nil {
+			o.cancelSaturation(job, saturationDir)
+			return
+		}
+
+		o.updateProgress(job, 60+done*35/total, "Subsampling reads",
+			fmt.Sprintf("Subsampling %s at %d depths", sample.SampleID, len(fractions)))
+
+		sampleDir := filepath.Join(saturationDir, sample.SampleID)
+		curve := o.saturationCurve(ctx, job, sample, sampleDir, indexPath, fractions, opts.Seed, minCounts, annotation, &done, total)
+		if ctx.Err() != nil {
+			o.cancelSaturation(job, saturationDir)
+			return
+		}
+		if curve.Error == "" {
+			assessSaturation(&curve, gainThreshold)
+			if !curve.Saturated {
+				summary.ShallowSamples = append(summary.ShallowSamples, curve.SampleID)
+			}
+			completed++
+		}
+		summary.Curves = append(summary.Curves, curve)
+	}
+
+	if completed == 0 {
+		o.failJob(job, "saturation failed: no sample completed")
+		return
+	}
+
+	curveFile := filepath.Join(saturationDir, "saturation.tsv")
+	if err := writeSaturationTable(curveFile, summary.Curves); err != nil {
+		o.logger.Warn("failed to write saturation table", zap.Error(err))
+	} else {
+		summary.CurveFile = curveFile
+	}
+
+	// Complete
+	job.Status = StatusCompleted
+	job.Progress = 100
+	job.Stage = "Completed"
+	job.Message = fmt.Sprintf("Saturation completed: %d samples, %d below saturation", completed, len(summary.ShallowSamples))
+	job.Output = &PipelineOutput{Saturation: summary}
+	now := time.Now()
+	job.CompletedAt = &now
+	o.jobs.Store(job.ID, job)
+
+	o.logger.Info("saturation completed",
+		zap.String("job_id", job.ID),
+		zap.Int("samples", completed),
+		zap.Strings("shallow", summary.ShallowSamples),
+		zap.Duration("duration", time.Since(startTime)),
+	)
+}
+
+// cancelSaturation marks a saturation job as cancelled and removes its
+// partial output.
+func (o *Orchestrator) cancelSaturation(job *PipelineJob, saturationDir string) {
+	o.cancelJob(job)
+	if err := os.RemoveAll(saturationDir); err != nil {
+		o.logger.Warn("failed to remove saturation output", zap.String("dir", saturationDir), zap.Error(err))
+	}
+}
+
+// saturationCurve subsamples one library and quantifies each depth. The
+// subsampled reads are removed once quantified.
+func (o *Orchestrator) saturationCurve(
+	ctx context.Context,
+	job *PipelineJob,
+	sample SaturationSample,
+	sampleDir, indexPath string,
+	fractions []float64,
+	seed int64,
+	minCounts float64,
+	annotation *reference.Annotation,
+	done *int,
+	total int,
+) SaturationCurve {
+	curve := SaturationCurve{SampleID: sample.SampleID}
+
+	subsamples, totalReads, err := quantify.SubsampleFASTQ(ctx, quantify.SubsampleOptions{
+		Reads1:    sample.Reads1,
+		Reads2:    sample.Reads2,
+		OutputDir: filepath.Join(sampleDir, "reads"),
+		Fractions: fractions,
+		Seed:      seed,
+	})
+	if err != nil {
+		curve.Error = "subsampling failed: " + err.Error()
+		*done += len(fractions)
+		return curve
+	}
+	defer os.RemoveAll(filepath.Join(sampleDir, "reads"))
+	curve.TotalReads = totalReads
+
+	for _, sub := range subsamples {
+		if ctx.Err() != nil {
+			return curve
+		}
+
+		o.updateProgress(job, 60+*done*35/total, "Quantifying subsamples",
+			fmt.Sprintf("Quantifying %s at %.0f%% depth", sample.SampleID, sub.Fraction*100))
+		*done++
+
+		point := SaturationPoint{Fraction: sub.Fraction, Reads: sub.Reads}
+		if sub.Reads > 0 {
+			result, err := o.kallisto.Quantify(ctx, quantify.QuantifyOptions{
+				SampleID:  fmt.Sprintf("%s_%.3f", sample.SampleID, sub.Fraction),
+				Reads1:    sub.Reads1,
+				Reads2:    sub.Reads2,
+				Index:     indexPath,
+				OutputDir: filepath.Join(sampleDir, "kallisto_"+quantify.FractionLabel(sub.Fraction)),
+			})
+			if err != nil {
+				curve.Error = fmt.Sprintf("quantification at %.0f%% depth failed: %v", sub.Fraction*100, err)
+				return curve
+			}
+			point.MappingRate = result.MappingRate
+			point.DetectedGenes, point.DetectedTranscripts = countDetected(result, annotation, minCounts)
+		}
+		curve.Points = append(curve.Points, point)
+	}
+
+	return curve
+}
+
+// countDetected returns the number of genes and transcripts whose estimated
+// counts reach minCounts. Transcripts are collapsed to genes with the
+// annotation; without one, genes are counted as transcripts.
+func countDetected(result *models.QuantificationResult, annotation *reference.Annotation, minCounts float64) (int, int) {
+	geneCounts := make(map[string]float64)
+	transcripts := 0
+	for _, t := range result.Transcripts {
+		if t.EstCounts >= minCounts {
+			transcripts++
+		}
+		geneID := t.TranscriptID
+		if annotation != nil {
+			if gene, ok := annotation.Lookup(t.TranscriptID); ok && gene.GeneID != "" {
+				geneID = gene.GeneID
+			}
+		}
+		geneCounts[geneID] += t.EstCounts
+	}
+
+	genes := 0
+	for _, counts := range geneCounts {
+		if counts >= minCounts {
+			genes++
+		}
+	}
+	return genes, transcripts
+}
+
+// assessSaturation measures the gain in detected genes over the last depth
+// step and flags libraries that are still far from saturation.
+func assessSaturation(curve *SaturationCurve, gainThreshold float64) {
+	n := len(curve.Points)
+	if n == 0 {
+		return
+	}
+	last := curve.Points[n-1]
+	curve.DetectedGenes = last.DetectedGenes
+	if n < 2 {
+		return
+	}
+
+	prev := curve.Points[n-2]
+	gained := float64(last.DetectedGenes - prev.DetectedGenes)
+	if prev.DetectedGenes > 0 {
+		curve.LastStepGain = gained / float64(prev.DetectedGenes) * 100
+	}
+	if reads := last.Reads - prev.Reads; reads > 0 {
+		curve.GenesPerMillion = gained / (float64(reads) / 1e6)
+	}
+
+	curve.Saturated = prev.DetectedGenes > 0 && curve.LastStepGain < gainThreshold
+	if curve.Saturated {
+		curve.Recommendation = "Library is near saturation; deeper sequencing would detect few additional genes"
+	} else {
+		curve.Recommendation = fmt.Sprintf("Detected genes grew %.1f%% over the last %.0f%% of reads; consider re-sequencing this library deeper",
+			curve.LastStepGain, (last.Fraction-prev.Fraction)*100)
+	}
+}
+
+// writeSaturationTable writes all curves as a long-format TSV for plotting.
+func writeSaturationTable(path string, curves []SaturationCurve) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	writer.WriteString("sample_id\tfraction\treads\tmapping_rate\tdetected_genes\tdetected_transcripts\n")
+	for _, curve := range curves {
+		for _, p := range curve.Points {
+			fmt.Fprintf(writer, "%s\t%.3f\t%d\t%.2f\t%d\t%d\n",
+				curve.SampleID, p.Fraction, p.Reads, p.MappingRate, p.DetectedGenes, p.DetectedTranscripts)
+		}
+	}
+	return writer.Flush()
+}
+
+// saturationFractions returns the sorted, de-duplicated depths of an
+// analysis, always ending with the full library.
+func saturationFractions(fractions []float64) []float64 {
+	if len(fractions) == 0 {
+		fractions = defaultSaturationFractions
+	}
+
+	seen := map[float64]bool{1: true}
+	result := make([]float64, 0, len(fractions)+1)
+	for _, f := range fractions {
+		if f > 0 && f < 1 && !seen[f] {
+			seen[f] = true
+			result = append(result, f)
+		}
+	}
+	result = append(result, 1)
+	sort.Float64s(result)
+	return result
+}
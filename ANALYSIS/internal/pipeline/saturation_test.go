@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/models"
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/reference"
+	"go.uber.org/zap"
+)
+
+func TestSaturationFractions(t *testing.T) {
+	tests := []struct {
+		name      string
+		fractions []float64
+		want      []float64
+	}{
+		{"defaults", nil, []float64{0.1, 0.25, 0.5, 0.75, 1}},
+		{"sorted with full library", []float64{0.5, 0.2}, []float64{0.2, 0.5, 1}},
+		{"duplicates removed", []float64{0.3, 0.3, 1, 1}, []float64{0.3, 1}},
+		{"out of range ignored", []float64{0, -0.5, 1.5, 0.4}, []float64{0.4, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := saturationFractions(tt.fractions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("saturationFractions(%v) = %v, want %v", tt.fractions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssessSaturation(t *testing.T) {
+	tests := []struct {
+		name           string
+		points         []SaturationPoint
+		wantGenes      int
+		wantGain       float64
+		wantPerMillion float64
+		wantSaturated  bool
+	}{
+		{
+			name:      "no points",
+			wantGenes: 0,
+		},
+		{
+			name:      "single point",
+			points:    []SaturationPoint{{Fraction: 1, Reads: 1e6, DetectedGenes: 900}},
+			wantGenes: 900,
+		},
+		{
+			name: "saturated",
+			points: []SaturationPoint{
+				{Fraction: 0.5, Reads: 1e6, DetectedGenes: 1000},
+				{Fraction: 1, Reads: 2e6, DetectedGenes: 1010},
+			},
+			wantGenes: 1010, wantGain: 1, wantPerMillion: 10, wantSaturated: true,
+		},
+		{
+			name: "still growing",
+			points: []SaturationPoint{
+				{Fraction: 0.75, Reads: 3e6, DetectedGenes: 800},
+				{Fraction: 1, Reads: 4e6, DetectedGenes: 1000},
+			},
+			wantGenes: 1000, wantGain: 25, wantPerMillion: 200,
+		},
+		{
+			name: "nothing detected at the previous depth",
+			points: []SaturationPoint{
+				{Fraction: 0.5, Reads: 1e6},
+				{Fraction: 1, Reads: 2e6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curve := &SaturationCurve{Points: tt.points}
+			assessSaturation(curve, 5)
+
+			if curve.DetectedGenes != tt.wantGenes {
+				t.Errorf("DetectedGenes = %d, want %d", curve.DetectedGenes, tt.wantGenes)
+			}
+			if curve.LastStepGain != tt.wantGain {
+				t.Errorf("LastStepGain = %v, want %v", curve.LastStepGain, tt.wantGain)
+			}
+			if curve.GenesPerMillion != tt.wantPerMillion {
+				t.Errorf("GenesPerMillion = %v, want %v", curve.GenesPerMillion, tt.wantPerMillion)
+			}
+			if curve.Saturated != tt.wantSaturated {
+				t.Errorf("Saturated = %v, want %v", curve.Saturated, tt.wantSaturated)
+			}
+			if len(tt.points) > 1 && curve.Recommendation == "" {
+				t.Error("missing recommendation")
+			}
+		})
+	}
+}
+
+func TestCountDetected(t *testing.T) {
+	// ENST1 and ENST2 belong to G1, ENST3 to G2; ENST4 is not annotated
+	dir := t.TempDir()
+	table := "transcript_id\tgene_id\tsymbol\tdescription\n" +
+		"ENST1.1\tG1\tGENE1\tfirst gene\n" +
+		"ENST2\tG1\tGENE1\tfirst gene\n" +
+		"ENST3\tG2\tGENE2\tsecond gene\n"
+	if err := os.WriteFile(filepath.Join(dir, "homo_sapiens_annotation.tsv"), []byte(table), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager := reference.NewManager(context.Background(), dir, "kallisto", zap.NewNop())
+	annotation, err := manager.Annotation(context.Background(), "homo_sapiens")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := &models.QuantificationResult{Transcripts: []models.TranscriptCount{
+		{TranscriptID: "ENST1.1", EstCounts: 0.6},
+		{TranscriptID: "ENST2.3", EstCounts: 0.6}, // Version not in the table
+		{TranscriptID: "ENST3.1", EstCounts: 5},
+		{TranscriptID: "ENST4.1", EstCounts: 2},
+		{TranscriptID: "ENST5.1", EstCounts: 0},
+	}}
+
+	tests := []struct {
+		name            string
+		annotation      *reference.Annotation
+		minCounts       float64
+		wantGenes       int
+		wantTranscripts int
+	}{
+		// G1 reaches 1.2 counts from two transcripts below the threshold
+		{"collapsed to genes", annotation, 1, 3, 2},
+		{"without annotation", nil, 1, 2, 2},
+		{"higher threshold", annotation, 3, 1, 1},
+		{"everything detected", nil, 0, 5, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			genes, transcripts := countDetected(result, tt.annotation, tt.minCounts)
+			if genes != tt.wantGenes || transcripts != tt.wantTranscripts {
+				t.Errorf("countDetected = %d genes, %d transcripts, want %d, %d",
+					genes, transcripts, tt.wantGenes, tt.wantTranscripts)
+			}
+		})
+	}
+}
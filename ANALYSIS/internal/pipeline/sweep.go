@@ -0,0 +1,596 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/models"
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/quantify"
+	"github.com/guidiju-50/pandora/SHARED/discovery"
+	"go.uber.org/zap"
+)
+
+// maxSweepCombinations bounds the number of parameter combinations in a sweep.
+const maxSweepCombinations = 32
+
+// SweepGrid lists the parameter values combined in a sweep. Empty lists fall
+// back to the job's base parameters.
+type SweepGrid struct {
+	Leading       []int    `json:"leading"`
+	Trailing      []int    `json:"trailing"`
+	SlidingWindow []string `json:"sliding_window"`
+	MinLen        []int    `json:"min_len"`
+	CropLength    []int    `json:"crop_length"`
+	// Kallisto fragment length model (single-end reads only)
+	FragLength []float64 `json:"frag_length"`
+	FragSD     []float64 `json:"frag_sd"`
+}
+
+// SweepPoint is a single combination of trimming and quantification parameters.
+type SweepPoint struct {
+	Leading       int     `json:"leading"`
+	Trailing      int     `json:"trailing"`
+	SlidingWindow string  `json:"sliding_window"`
+	MinLen        int     `json:"min_len"`
+	CropLength    int     `json:"crop_length"`
+	FragLength    float64 `json:"frag_length,omitempty"`
+	FragSD        float64 `json:"frag_sd,omitempty"`
+}
+
+// SweepResult holds the outcome of one parameter combination.
+type SweepResult struct {
+	Params          SweepPoint `json:"params"`
+	InputReads      int64      `json:"input_reads"`
+	SurvivingReads  int64      `json:"surviving_reads"`
+	SurvivalRate    float64    `json:"survival_rate"`
+	TotalReads      int64      `json:"total_reads"`
+	MappedReads     int64      `json:"mapped_reads"`
+	MappingRate     float64    `json:"mapping_rate"`
+	TranscriptCount int        `json:"transcript_count"`
+	PearsonR        float64    `json:"pearson_r"`    // log2(TPM+1) against the reference
+	SpearmanRho     float64    `json:"spearman_rho"` // TPM ranks against the reference
+	KallistoDir     string     `json:"kallisto_dir"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// SweepSummary summarizes a parameter sweep. Correlations are measured
+// against the reference, the combination of the job's base parameters; they
+// are left at zero when that combination fails.
+type SweepSummary struct {
+	Reference       *SweepPoint   `json:"reference,omitempty"`
+	Results         []SweepResult `json:"results"`
+	BestMappingRate *SweepPoint   `json:"best_mapping_rate,omitempty"`
+}
+
+// trimRun holds the trimmed reads produced for one trimming combination.
+type trimRun struct {
+	files          []string
+	inputReads     int64
+	survivingReads int64
+	survivalRate   float64
+	err            error
+}
+
+// StartSweep validates the parameter grid and starts a sweep job.
+func (o *Orchestrator) StartSweep(ctx context.Context, input PipelineInput) (string, error) {
+	if input.Sweep == nil {
+		return "", fmt.Errorf("sweep grid is required")
+	}
+
+	trimPoints, quantPoints := expandGrid(input)
+	if n := len(trimPoints) * len(quantPoints); n > maxSweepCombinations {
+		return "", fmt.Errorf("sweep has %d combinations, maximum is %d", n, maxSweepCombinations)
+	}
+
+	return o.StartPipeline(ctx, input)
+}
+
+// runSweep downloads the accession once, then trims and quantifies it with
+// every combination of the parameter grid.
+func (o *Orchestrator) runSweep(ctx context.Context, job *PipelineJob) {
+	startTime := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &startTime
+	o.jobs.Store(job.ID, job)
+
+	defer func() {
+		if r := recover(); r != nil {
+			job.Status = StatusFailed
+			job.Error = fmt.Sprintf("sweep panicked: %v", r)
+			now := time.Now()
+			job.CompletedAt = &now
+			o.jobs.Store(job.ID, job)
+		}
+	}()
+
+	// Stage 1: Ensure reference index (0-20%)
+	o.updateProgress(job, 5, "Preparing reference index", "Checking Kallisto index for "+job.Input.Organism)
+
+	indexPath, err := o.ensureIndex(ctx, job)
+	if err != nil {
+		o.failJob(job, "reference preparation failed: "+err.Error())
+		return
+	}
+
+	// Stage 2: Download once via PROCESSING (20-60%)
+	o.updateProgress(job, 25, "Starting download", "Requesting download from PROCESSING module")
+
+	fastqFiles, err := o.downloadRaw(ctx, job)
+	if err != nil {
+		o.failJob(job, "download failed: "+err.Error())
+		return
+	}
+	if len(fastqFiles) == 0 {
+		o.failJob(job, "download failed: no raw FASTQ files found")
+		return
+	}
+	reads1, reads2 := pairReadFiles(fastqFiles)
+
+	// Stage 3: Trim and quantify every combination (60-95%)
+	// The base combination comes first, so it is the reference for the others
+	trimPoints, quantPoints := expandGrid(job.Input)
+	total := len(trimPoints) * len(quantPoints)
+	sweepDir := filepath.Join(o.outputDir, job.Input.Accession, "sweep", job.ID)
+	base := basePoint(job.Input)
+
+	summary := &SweepSummary{Results: make([]SweepResult, 0, total)}
+	var reference map[string]float64
+	done, completed := 0, 0
+
+	for i, trim := range trimPoints {
+		if ctx.Err() != nil {
+			return
+		}
+
+		trimDir := filepath.Join(sweepDir, fmt.Sprintf("trim_%d", i))
+		o.updateProgress(job, 60+done*35/total, "Sweeping parameters",
+			fmt.Sprintf("Trimming combination %d/%d", i+1, len(trimPoints)))
+		run := o.trimForSweep(ctx, reads1, reads2, trimDir, trim)
+
+		for j, quant := range quantPoints {
+			if ctx.Err() != nil {
+				return
+			}
+
+			point := trim
+			point.FragLength = quant.FragLength
+			point.FragSD = quant.FragSD
+			result := SweepResult{Params: point}
+
+			if run.err != nil {
+				result.Error = "trimming failed: " + run.err.Error()
+				summary.Results = append(summary.Results, result)
+				done++
+				continue
+			}
+			result.InputReads = run.inputReads
+			result.SurvivingReads = run.survivingReads
+			result.SurvivalRate = run.survivalRate
+
+			o.updateProgress(job, 60+done*35/total, "Sweeping parameters",
+				fmt.Sprintf("Quantifying combination %d/%d", done+1, total))
+
+			trimmed1, trimmed2 := pairReadFiles(run.files)
+			kallistoDir := filepath.Join(trimDir, fmt.Sprintf("kallisto_%d", j))
+			quantResult, err := o.kallisto.Quantify(ctx, quantify.QuantifyOptions{
+				SampleID:   job.Input.Accession,
+				Reads1:     trimmed1,
+				Reads2:     trimmed2,
+				Index:      indexPath,
+				OutputDir:  kallistoDir,
+				FragLength: quant.FragLength,
+				FragSD:     quant.FragSD,
+			})
+			if err != nil {
+				result.Error = "quantification failed: " + err.Error()
+				summary.Results = append(summary.Results, result)
+				done++
+				continue
+			}
+
+			result.KallistoDir = kallistoDir
+			result.TotalReads = quantResult.TotalReads
+			result.MappedReads = quantResult.MappedReads
+			result.MappingRate = quantResult.MappingRate
+			result.TranscriptCount = len(quantResult.Transcripts)
+
+			tpm := tpmByTranscript(quantResult)
+			if point == base {
+				reference = tpm
+				ref := point
+				summary.Reference = &ref
+			}
+			if reference != nil {
+				result.PearsonR, result.SpearmanRho = expressionCorrelation(reference, tpm)
+			}
+
+			summary.Results = append(summary.Results, result)
+			completed++
+			done++
+		}
+	}
+
+	if completed == 0 {
+		o.failJob(job, "sweep failed: no parameter combination completed")
+		return
+	}
+
+	best := -1
+	for i, r := range summary.Results {
+		if r.Error == "" && (best < 0 || r.MappingRate > summary.Results[best].MappingRate) {
+			best = i
+		}
+	}
+	bestPoint := summary.Results[best].Params
+	summary.BestMappingRate = &bestPoint
+
+	// Complete
+	job.Status = StatusCompleted
+	job.Progress = 100
+	job.Stage = "Completed"
+	job.Message = fmt.Sprintf("Sweep completed: %d combinations", total)
+	job.Output = &PipelineOutput{
+		FastqFiles: fastqFiles,
+		Sweep:      summary,
+	}
+	now := time.Now()
+	job.CompletedAt = &now
+	o.jobs.Store(job.ID, job)
+
+	o.logger.Info("sweep completed",
+		zap.String("job_id", job.ID),
+		zap.String("accession", job.Input.Accession),
+		zap.Int("combinations", total),
+		zap.Duration("duration", time.Since(startTime)),
+	)
+}
+
+// downloadRaw asks PROCESSING to download the accession without trimming and
+// waits for the download job. The job is polled on the instance that
+// accepted it, since jobs live in that instance's memory.
+func (o *Orchestrator) downloadRaw(ctx context.Context, job *PipelineJob) ([]string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"accessions": []string{job.Input.Accession},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := o.registry.Do(ctx, client, discovery.ServiceProcessing, http.MethodPost, "/api/v1/jobs/download", body, header)
+	if err != nil {
+		return nil, fmt.Errorf("calling PROCESSING: %w", err)
+	}
+	var created struct {
+		JobID string `json:"job_id"`
+		Error string `json:"error"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("decoding PROCESSING response: %w", err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("PROCESSING returned status %d: %s", resp.StatusCode, created.Error)
+	}
+
+	base := strings.TrimSuffix(resp.Request.URL.String(), "/api/v1/jobs/download")
+	jobURL := base + "/api/v1/jobs/" + created.JobID
+
+	// Poll every 5 seconds for up to 30 minutes
+	maxWait := 30 * time.Minute
+	startTime := time.Now()
+	for time.Since(startTime) < maxWait {
+		select {
+		case <-ctx.Done():
+			o.cancelRemoteJob(client, jobURL)
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+
+		var remote struct {
+			Status   string `json:"status"`
+			Progress int    `json:"progress"`
+			Error    string `json:"error"`
+			Output   struct {
+				Results []*struct {
+					Files []string `json:"files"`
+					Error string   `json:"error"`
+				} `json:"results"`
+			} `json:"output"`
+		}
+		if err := getJSON(ctx, client, jobURL, &remote); err != nil {
+			o.logger.Warn("polling download job failed", zap.String("job_id", created.JobID), zap.Error(err))
+			continue
+		}
+
+		switch remote.Status {
+		case "completed":
+			if len(remote.Output.Results) == 0 || remote.Output.Results[0] == nil {
+				return nil, fmt.Errorf("no download result for %s", job.Input.Accession)
+			}
+			result := remote.Output.Results[0]
+			if len(result.Files) == 0 {
+				return nil, fmt.Errorf("no FASTQ files downloaded: %s", result.Error)
+			}
+			return result.Files, nil
+		case "failed", "cancelled":
+			return nil, fmt.Errorf("download job %s: %s", remote.Status, remote.Error)
+		}
+
+		o.updateProgress(job, 25+remote.Progress*35/100, "Downloading",
+			fmt.Sprintf("Waiting for PROCESSING download (%v elapsed)", time.Since(startTime).Round(time.Second)))
+	}
+
+	o.cancelRemoteJob(client, jobURL)
+	return nil, fmt.Errorf("download did not finish within %v", maxWait)
+}
+
+// getJSON fetches url and decodes its JSON body into v.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// cancelRemoteJob asks PROCESSING to cancel a job, ignoring failures.
+func (o *Orchestrator) cancelRemoteJob(client *http.Client, jobURL string) {
+	resp, err := client.Post(jobURL+"/cancel", "application/json", nil)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// trimForSweep asks PROCESSING to trim the raw reads with one parameter combination.
+func (o *Orchestrator) trimForSweep(ctx context.Context, reads1, reads2, outputDir string, point SweepPoint) trimRun {
+	body, err := json.Marshal(map[string]interface{}{
+		"input_file_1":   reads1,
+		"input_file_2":   reads2,
+		"output_dir":     outputDir,
+		"leading":        point.Leading,
+		"trailing":       point.Trailing,
+		"sliding_window": point.SlidingWindow,
+		"min_len":        point.MinLen,
+		"crop_length":    point.CropLength,
+	})
+	if err != nil {
+		return trimRun{err: err}
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Minute}
+	resp, err := o.registry.Do(ctx, client, discovery.ServiceProcessing, http.MethodPost, "/api/v1/jobs/process", body, header)
+	if err != nil {
+		return trimRun{err: fmt.Errorf("calling PROCESSING: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		Error  string `json:"error"`
+		Result struct {
+			InputReads   int64    `json:"input_reads"`
+			OutputReads  int64    `json:"output_reads"`
+			SurvivalRate float64  `json:"survival_rate"`
+			OutputFiles  []string `json:"output_files"`
+		} `json:"result"`
+		Crop *struct {
+			OutputReads int64    `json:"output_reads"`
+			OutputFiles []string `json:"output_files"`
+		} `json:"crop"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return trimRun{err: fmt.Errorf("decoding PROCESSING response: %w", err)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return trimRun{err: fmt.Errorf("PROCESSING returned status %d: %s", resp.StatusCode, payload.Error)}
+	}
+
+	run := trimRun{
+		files:          payload.Result.OutputFiles,
+		inputReads:     payload.Result.InputReads,
+		survivingReads: payload.Result.OutputReads,
+		survivalRate:   payload.Result.SurvivalRate,
+	}
+	if payload.Crop != nil {
+		run.files = payload.Crop.OutputFiles
+		run.survivingReads = payload.Crop.OutputReads
+	}
+	if len(run.files) == 0 {
+		run.err = fmt.Errorf("no trimmed files produced")
+	}
+	return run
+}
+
+// basePoint returns the combination of the job's own parameters with the
+// default Kallisto fragment length model.
+func basePoint(input PipelineInput) SweepPoint {
+	return SweepPoint{
+		Leading:       getOrDefault(input.Leading, 3),
+		Trailing:      getOrDefault(input.Trailing, 3),
+		SlidingWindow: getOrDefaultStr(input.SlidingWindow, "4:15"),
+		MinLen:        getOrDefault(input.MinLen, 36),
+		CropLength:    input.CropLength,
+	}
+}
+
+// expandGrid returns the trimming and quantification combinations of a sweep.
+// The base combination is always included and comes first in both lists.
+func expandGrid(input PipelineInput) ([]SweepPoint, []SweepPoint) {
+	grid := input.Sweep
+	if grid == nil {
+		grid = &SweepGrid{}
+	}
+
+	leading := intsOrDefault(grid.Leading, getOrDefault(input.Leading, 3))
+	trailing := intsOrDefault(grid.Trailing, getOrDefault(input.Trailing, 3))
+	windows := grid.SlidingWindow
+	if len(windows) == 0 {
+		windows = []string{getOrDefaultStr(input.SlidingWindow, "4:15")}
+	}
+	minLens := intsOrDefault(grid.MinLen, getOrDefault(input.MinLen, 36))
+	cropLens := intsOrDefault(grid.CropLength, input.CropLength)
+
+	base := basePoint(input)
+	trimPoints := []SweepPoint{base}
+	for _, l := range leading {
+		for _, t := range trailing {
+			for _, w := range windows {
+				for _, m := range minLens {
+					for _, c := range cropLens {
+						point := SweepPoint{
+							Leading:       l,
+							Trailing:      t,
+							SlidingWindow: w,
+							MinLen:        m,
+							CropLength:    c,
+						}
+						if point != base {
+							trimPoints = append(trimPoints, point)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	fragLens := floatsOrDefault(grid.FragLength, 0)
+	fragSDs := floatsOrDefault(grid.FragSD, 0)
+
+	quantPoints := []SweepPoint{{}}
+	for _, l := range fragLens {
+		for _, s := range fragSDs {
+			if point := (SweepPoint{FragLength: l, FragSD: s}); point != (SweepPoint{}) {
+				quantPoints = append(quantPoints, point)
+			}
+		}
+	}
+
+	return trimPoints, quantPoints
+}
+
+// tpmByTranscript indexes TPM values by transcript ID.
+func tpmByTranscript(result *models.QuantificationResult) map[string]float64 {
+	tpm := make(map[string]float64, len(result.Transcripts))
+	for _, t := range result.Transcripts {
+		tpm[t.TranscriptID] = t.TPM
+	}
+	return tpm
+}
+
+// expressionCorrelation returns the Pearson correlation of log2(TPM+1) and the
+// Spearman correlation of TPM over the union of transcripts in a and b.
+func expressionCorrelation(a, b map[string]float64) (float64, float64) {
+	ids := make([]string, 0, len(a))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	for id := range b {
+		if _, ok := a[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	x := make([]float64, len(ids))
+	y := make([]float64, len(ids))
+	for i, id := range ids {
+		x[i] = a[id]
+		y[i] = b[id]
+	}
+
+	logX := make([]float64, len(x))
+	logY := make([]float64, len(y))
+	for i := range x {
+		logX[i] = math.Log2(x[i] + 1)
+		logY[i] = math.Log2(y[i] + 1)
+	}
+
+	return pearson(logX, logY), pearson(ranks(x), ranks(y))
+}
+
+// pearson returns the Pearson correlation coefficient of x and y.
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY float64
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// ranks returns the fractional ranks of values, averaging ties.
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	r := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			r[order[k]] = avg
+		}
+		i = j + 1
+	}
+	return r
+}
+
+func intsOrDefault(vals []int, def int) []int {
+	if len(vals) == 0 {
+		return []int{def}
+	}
+	return vals
+}
+
+func floatsOrDefault(vals []float64, def float64) []float64 {
+	if len(vals) == 0 {
+		return []float64{def}
+	}
+	return vals
+}
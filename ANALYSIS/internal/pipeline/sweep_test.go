@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestExpandGrid(t *testing.T) {
+	base := SweepPoint{Leading: 3, Trailing: 3, SlidingWindow: "4:15", MinLen: 36}
+
+	tests := []struct {
+		name      string
+		input     PipelineInput
+		wantTrim  []SweepPoint
+		wantQuant []SweepPoint
+	}{
+		{
+			name:      "no grid",
+			input:     PipelineInput{},
+			wantTrim:  []SweepPoint{base},
+			wantQuant: []SweepPoint{{}},
+		},
+		{
+			name:  "base value in grid is not repeated",
+			input: PipelineInput{Sweep: &SweepGrid{Leading: []int{5, 3}}},
+			wantTrim: []SweepPoint{
+				base,
+				{Leading: 5, Trailing: 3, SlidingWindow: "4:15", MinLen: 36},
+			},
+			wantQuant: []SweepPoint{{}},
+		},
+		{
+			name:  "base added when the grid excludes it",
+			input: PipelineInput{MinLen: 50, Sweep: &SweepGrid{Trailing: []int{10, 20}}},
+			wantTrim: []SweepPoint{
+				{Leading: 3, Trailing: 3, SlidingWindow: "4:15", MinLen: 50},
+				{Leading: 3, Trailing: 10, SlidingWindow: "4:15", MinLen: 50},
+				{Leading: 3, Trailing: 20, SlidingWindow: "4:15", MinLen: 50},
+			},
+			wantQuant: []SweepPoint{{}},
+		},
+		{
+			name:     "fragment length grid",
+			input:    PipelineInput{Sweep: &SweepGrid{FragLength: []float64{180, 250}, FragSD: []float64{20}}},
+			wantTrim: []SweepPoint{base},
+			wantQuant: []SweepPoint{
+				{},
+				{FragLength: 180, FragSD: 20},
+				{FragLength: 250, FragSD: 20},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trim, quant := expandGrid(tt.input)
+			if !reflect.DeepEqual(trim, tt.wantTrim) {
+				t.Errorf("trim points = %+v, want %+v", trim, tt.wantTrim)
+			}
+			if !reflect.DeepEqual(quant, tt.wantQuant) {
+				t.Errorf("quant points = %+v, want %+v", quant, tt.wantQuant)
+			}
+			if trim[0] != basePoint(tt.input) {
+				t.Errorf("first trim point %+v is not the base %+v", trim[0], basePoint(tt.input))
+			}
+		})
+	}
+}
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64
+	}{
+		{"perfect positive", []float64{1, 2, 3}, []float64{2, 4, 6}, 1},
+		{"perfect negative", []float64{1, 2, 3}, []float64{3, 2, 1}, -1},
+		{"uncorrelated", []float64{1, 2, 3, 4}, []float64{1, -1, -1, 1}, 0},
+		{"constant x", []float64{5, 5, 5}, []float64{1, 2, 3}, 0},
+		{"constant y", []float64{1, 2, 3}, []float64{0, 0, 0}, 0},
+		{"single value", []float64{1}, []float64{1}, 0},
+		{"empty", nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pearson(tt.x, tt.y); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("pearson = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRanks(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   []float64
+	}{
+		{"distinct", []float64{30, 10, 20}, []float64{3, 1, 2}},
+		{"ties averaged", []float64{5, 1, 5, 3}, []float64{3.5, 1, 3.5, 2}},
+		{"all tied", []float64{2, 2, 2}, []float64{2, 2, 2}},
+		{"empty", []float64{}, []float64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ranks(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ranks(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionCorrelation(t *testing.T) {
+	tests := []struct {
+		name         string
+		a, b         map[string]float64
+		wantPearson  float64
+		wantSpearman float64
+	}{
+		{
+			name:         "identical",
+			a:            map[string]float64{"t1": 1, "t2": 10, "t3": 100},
+			b:            map[string]float64{"t1": 1, "t2": 10, "t3": 100},
+			wantPearson:  1,
+			wantSpearman: 1,
+		},
+		{
+			name:         "monotonic but not linear",
+			a:            map[string]float64{"t1": 1, "t2": 3, "t3": 7},
+			b:            map[string]float64{"t1": 0, "t2": 1, "t3": 63},
+			wantPearson:  pearson([]float64{1, 2, 3}, []float64{0, 1, 6}),
+			wantSpearman: 1,
+		},
+		{
+			name:         "missing transcripts count as zero",
+			a:            map[string]float64{"t1": 3, "t2": 7},
+			b:            map[string]float64{"t2": 7, "t3": 3},
+			wantPearson:  1.0 / 7,
+			wantSpearman: 0.5,
+		},
+		{
+			name:         "constant expression",
+			a:            map[string]float64{"t1": 4, "t2": 4},
+			b:            map[string]float64{"t1": 1, "t2": 9},
+			wantPearson:  0,
+			wantSpearman: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, s := expressionCorrelation(tt.a, tt.b)
+			if math.Abs(p-tt.wantPearson) > 1e-9 || math.Abs(s-tt.wantSpearman) > 1e-9 {
+				t.Errorf("correlation = %v, %v, want %v, %v", p, s, tt.wantPearson, tt.wantSpearman)
+			}
+		})
+	}
+}
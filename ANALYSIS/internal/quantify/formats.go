@@ -0,0 +1,323 @@
+// Package quantify provides RNA-seq quantification tools.
+package quantify
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AbundanceFormat identifies the tool that produced an abundance file.
+type AbundanceFormat string
+
+const (
+	FormatKallisto      AbundanceFormat = "kallisto"      // abundance.tsv
+	FormatSalmon        AbundanceFormat = "salmon"        // quant.sf
+	FormatFeatureCounts AbundanceFormat = "featurecounts" // featureCounts -o output
+	FormatStringTie     AbundanceFormat = "stringtie"     // stringtie -e -o transcript GTF
+)
+
+// DefaultReadLength is the read length used to estimate counts from StringTie
+// coverage when none is given, matching the prepDE.py default.
+const DefaultReadLength = 75
+
+// ErrInvalidAbundance is returned when an abundance file cannot be imported.
+var ErrInvalidAbundance = errors.New("invalid abundance file")
+
+// ParseAbundanceFormat validates a format name. An empty name means the
+// format is detected from the file header.
+func ParseAbundanceFormat(name string) (AbundanceFormat, error) {
+	switch format := AbundanceFormat(strings.ToLower(name)); format {
+	case "", FormatKallisto, FormatSalmon, FormatFeatureCounts, FormatStringTie:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported abundance format: %s", name)
+}
+
+// DetectAbundanceFormat infers the format of an abundance file from its header.
+func DetectAbundanceFormat(path string) (AbundanceFormat, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening abundance file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# Program:featureCounts") {
+			return FormatFeatureCounts, nil
+		}
+		if strings.HasPrefix(line, "# stringtie") || strings.HasPrefix(line, "# StringTie") {
+			return FormatStringTie, nil
+		}
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		header := strings.Split(line, "\t")
+		switch {
+		case len(header) == 9 && header[1] == "StringTie":
+			return FormatStringTie, nil
+		case header[0] == "Gene ID":
+			return "", fmt.Errorf("StringTie gene abundance tables (-A) have no transcript lengths; import the transcript GTF written with -e -o instead")
+		case header[0] == "target_id":
+			return FormatKallisto, nil
+		case header[0] == "Name" && len(header) >= 5 && header[4] == "NumReads":
+			return FormatSalmon, nil
+		case header[0] == "Geneid":
+			return FormatFeatureCounts, nil
+		}
+		return "", fmt.Errorf("unrecognized abundance header in %s", path)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading abundance file: %w", err)
+	}
+	return "", fmt.Errorf("empty abundance file: %s", path)
+}
+
+// LoadAbundanceFile loads TPM values and estimated counts for a sample from an
+// abundance file. When format is empty it is detected from the header.
+// readLength is the sequenced read length used to estimate StringTie counts
+// (DefaultReadLength when zero).
+func LoadAbundanceFile(sampleID, path string, format AbundanceFormat, readLength int) (*SampleData, error) {
+	if format == "" {
+		detected, err := DetectAbundanceFormat(path)
+		if err != nil {
+			return nil, err
+		}
+		format = detected
+	}
+	if format == FormatStringTie {
+		if readLength <= 0 {
+			readLength = DefaultReadLength
+		}
+		return loadStringTieGTF(sampleID, path, readLength)
+	}
+
+	var parse func(fields []string, data *SampleData) error
+	columns := 0
+	switch format {
+	case FormatKallisto:
+		// target_id, length, eff_length, est_counts, tpm
+		parse, columns = parseKallistoRow, 5
+	case FormatSalmon:
+		// Name, Length, EffectiveLength, TPM, NumReads
+		parse, columns = parseSalmonRow, 5
+	case FormatFeatureCounts:
+		// Geneid, Chr, Start, End, Strand, Length, <count>
+		parse, columns = parseFeatureCountsRow, 7
+	default:
+		return nil, fmt.Errorf("unsupported abundance format: %s", format)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening abundance file: %w", err)
+	}
+	defer file.Close()
+
+	data := &SampleData{
+		SampleID:    sampleID,
+		Expressions: make(map[string]float64),
+		Counts:      make(map[string]float64),
+	}
+	lengths := make(map[string]float64)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	headerSeen := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		// Skip header
+		if !headerSeen {
+			headerSeen = true
+			// featureCounts writes one count column per BAM file
+			if n := len(strings.Split(line, "\t")); format == FormatFeatureCounts && n > columns {
+				return nil, fmt.Errorf("featureCounts file has %d count columns; import a file with one sample", n-columns+1)
+			}
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < columns {
+			continue
+		}
+		if err := parse(fields, data); err != nil {
+			continue
+		}
+		if format == FormatFeatureCounts {
+			length, _ := strconv.ParseFloat(fields[5], 64)
+			lengths[fields[0]] = length
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading abundance file: %w", err)
+	}
+
+	if format == FormatFeatureCounts {
+		data.Expressions = countsToTPM(data.Counts, lengths)
+	}
+
+	return data, nil
+}
+
+func parseKallistoRow(fields []string, data *SampleData) error {
+	counts, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil {
+		return err
+	}
+	tpm, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return err
+	}
+	data.Counts[fields[0]] = counts
+	data.Expressions[fields[0]] = tpm
+	return nil
+}
+
+func parseSalmonRow(fields []string, data *SampleData) error {
+	tpm, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil {
+		return err
+	}
+	counts, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return err
+	}
+	data.Counts[fields[0]] = counts
+	data.Expressions[fields[0]] = tpm
+	return nil
+}
+
+func parseFeatureCountsRow(fields []string, data *SampleData) error {
+	counts, err := strconv.ParseFloat(fields[6], 64)
+	if err != nil {
+		return err
+	}
+	data.Counts[fields[0]] = counts
+	return nil
+}
+
+// stringTieTranscript holds the fields of one transcript of a StringTie GTF.
+type stringTieTranscript struct {
+	geneID   string
+	coverage float64
+	tpm      float64
+	length   float64 // Sum of exon lengths
+}
+
+// loadStringTieGTF loads gene-level TPM values and counts from the transcript
+// GTF written by "stringtie -e -o". As in prepDE.py, the counts of a
+// transcript are coverage × transcript length / read length rounded up, and
+// genes sum their transcripts.
+func loadStringTieGTF(sampleID, path string, readLength int) (*SampleData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening abundance file: %w", err)
+	}
+	defer file.Close()
+
+	transcripts := make(map[string]*stringTieTranscript)
+	transcript := func(id string) *stringTieTranscript {
+		t, ok := transcripts[id]
+		if !ok {
+			t = &stringTieTranscript{}
+			transcripts[id] = t
+		}
+		return t
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 9 {
+			continue
+		}
+		transcriptID := gtfAttribute(fields[8], "transcript_id")
+		if transcriptID == "" {
+			continue
+		}
+
+		switch fields[2] {
+		case "transcript":
+			t := transcript(transcriptID)
+			t.geneID = gtfAttribute(fields[8], "gene_id")
+			t.coverage, _ = strconv.ParseFloat(gtfAttribute(fields[8], "cov"), 64)
+			t.tpm, _ = strconv.ParseFloat(gtfAttribute(fields[8], "TPM"), 64)
+		case "exon":
+			start, err1 := strconv.ParseFloat(fields[3], 64)
+			end, err2 := strconv.ParseFloat(fields[4], 64)
+			if err1 == nil && err2 == nil {
+				transcript(transcriptID).length += end - start + 1
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading abundance file: %w", err)
+	}
+
+	data := &SampleData{
+		SampleID:    sampleID,
+		Expressions: make(map[string]float64),
+		Counts:      make(map[string]float64),
+	}
+	for _, t := range transcripts {
+		if t.geneID == "" {
+			continue
+		}
+		data.Counts[t.geneID] += math.Ceil(t.coverage * t.length / float64(readLength))
+		data.Expressions[t.geneID] += t.tpm
+	}
+	return data, nil
+}
+
+// gtfAttribute returns the value of key in a GTF attribute column.
+func gtfAttribute(column, key string) string {
+	for _, part := range strings.Split(column, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), " ")
+		if ok && name == key {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// countsToTPM converts raw counts to TPM using feature lengths.
+func countsToTPM(counts, lengths map[string]float64) map[string]float64 {
+	rates := make(map[string]float64, len(counts))
+	total := 0.0
+	for id, count := range counts {
+		length := lengths[id]
+		if length <= 0 {
+			continue
+		}
+		rate := count / (length / 1000)
+		rates[id] = rate
+		total += rate
+	}
+
+	tpm := make(map[string]float64, len(counts))
+	for id := range counts {
+		if total > 0 {
+			tpm[id] = rates[id] / total * 1e6
+		} else {
+			tpm[id] = 0
+		}
+	}
+	return tpm
+}
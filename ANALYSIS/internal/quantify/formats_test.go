@@ -0,0 +1,230 @@
+package quantify
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const stringTieGTF = `# stringtie -e -G genes.gtf -o sample.gtf sample.bam
+# StringTie version 2.2.1
+chr1	StringTie	transcript	100	399	1000	+	.	gene_id "G1"; transcript_id "T1"; cov "10.0"; FPKM "5.0"; TPM "30.0";
+chr1	StringTie	exon	100	199	1000	+	.	gene_id "G1"; transcript_id "T1"; exon_number "1"; cov "10.0";
+chr1	StringTie	exon	300	399	1000	+	.	gene_id "G1"; transcript_id "T1"; exon_number "2"; cov "10.0";
+chr1	StringTie	transcript	100	249	1000	+	.	gene_id "G1"; transcript_id "T2"; cov "2.0"; FPKM "1.0"; TPM "10.0";
+chr1	StringTie	exon	100	249	1000	+	.	gene_id "G1"; transcript_id "T2"; exon_number "1"; cov "2.0";
+chr2	StringTie	transcript	10	59	1000	-	.	gene_id "G2"; transcript_id "T3"; cov "1.5"; FPKM "0.5"; TPM "60.0";
+chr2	StringTie	exon	10	59	1000	-	.	gene_id "G2"; transcript_id "T3"; exon_number "1"; cov "1.5";
+`
+
+func writeAbundance(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDetectAbundanceFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    AbundanceFormat
+		wantErr string
+	}{
+		{
+			name:    "kallisto",
+			content: "target_id\tlength\teff_length\test_counts\ttpm\nT1\t100\t80\t5\t1.5\n",
+			want:    FormatKallisto,
+		},
+		{
+			name:    "salmon",
+			content: "Name\tLength\tEffectiveLength\tTPM\tNumReads\nT1\t100\t80\t1.5\t5\n",
+			want:    FormatSalmon,
+		},
+		{
+			name:    "featureCounts with program comment",
+			content: "# Program:featureCounts v2.0.1; Command:\"featureCounts\"\nGeneid\tChr\tStart\tEnd\tStrand\tLength\ts1.bam\n",
+			want:    FormatFeatureCounts,
+		},
+		{
+			name:    "featureCounts without comment",
+			content: "Geneid\tChr\tStart\tEnd\tStrand\tLength\ts1.bam\n",
+			want:    FormatFeatureCounts,
+		},
+		{
+			name:    "stringtie GTF",
+			content: stringTieGTF,
+			want:    FormatStringTie,
+		},
+		{
+			name:    "stringtie GTF without comments",
+			content: strings.SplitN(stringTieGTF, "\n", 3)[2],
+			want:    FormatStringTie,
+		},
+		{
+			name:    "stringtie gene abundance table",
+			content: "Gene ID\tGene Name\tReference\tStrand\tStart\tEnd\tCoverage\tFPKM\tTPM\n",
+			wantErr: "transcript GTF",
+		},
+		{
+			name:    "unknown header",
+			content: "gene\tvalue\nG1\t3\n",
+			wantErr: "unrecognized",
+		},
+		{
+			name:    "empty",
+			content: "# only a comment\n\n",
+			wantErr: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectAbundanceFormat(writeAbundance(t, "abundance.tsv", tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("format = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAbundanceFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		format     AbundanceFormat
+		readLength int
+		wantCounts map[string]float64
+		wantTPM    map[string]float64
+		wantErr    string
+	}{
+		{
+			name:       "kallisto",
+			content:    "target_id\tlength\teff_length\test_counts\ttpm\nT1\t100\t80\t5\t1.5\nT2\t200\t180\tbad\t2\nT3\t300\t280\t7\t3.5\n",
+			format:     FormatKallisto,
+			wantCounts: map[string]float64{"T1": 5, "T3": 7},
+			wantTPM:    map[string]float64{"T1": 1.5, "T3": 3.5},
+		},
+		{
+			name:       "salmon",
+			content:    "Name\tLength\tEffectiveLength\tTPM\tNumReads\nT1\t100\t80\t1.5\t5\nT2\t200\t180\t2.5\t8\n",
+			format:     FormatSalmon,
+			wantCounts: map[string]float64{"T1": 5, "T2": 8},
+			wantTPM:    map[string]float64{"T1": 1.5, "T2": 2.5},
+		},
+		{
+			name: "featureCounts",
+			content: "# Program:featureCounts v2.0.1\n" +
+				"Geneid\tChr\tStart\tEnd\tStrand\tLength\ts1.bam\n" +
+				"G1\tchr1\t1\t1000\t+\t1000\t100\n" +
+				"G2\tchr1\t2001\t2500\t+\t500\t100\n",
+			format:     FormatFeatureCounts,
+			wantCounts: map[string]float64{"G1": 100, "G2": 100},
+			// Rates 0.1 and 0.2 per base scale to one million
+			wantTPM: map[string]float64{"G1": 1e6 / 3, "G2": 2e6 / 3},
+		},
+		{
+			name: "featureCounts with several samples",
+			content: "Geneid\tChr\tStart\tEnd\tStrand\tLength\ts1.bam\ts2.bam\n" +
+				"G1\tchr1\t1\t1000\t+\t1000\t100\t120\n",
+			format:  FormatFeatureCounts,
+			wantErr: "2 count columns",
+		},
+		{
+			// T1: 10 × 200 / 75 = 26.7 -> 27, T2: 2 × 150 / 75 = 4, T3: 1.5 × 50 / 75 = 1
+			name:       "stringtie default read length",
+			content:    stringTieGTF,
+			format:     FormatStringTie,
+			wantCounts: map[string]float64{"G1": 31, "G2": 1},
+			wantTPM:    map[string]float64{"G1": 40, "G2": 60},
+		},
+		{
+			// T1: 10 × 200 / 100 = 20, T2: 2 × 150 / 100 = 3, T3: 1.5 × 50 / 100 = 0.75 -> 1
+			name:       "stringtie custom read length",
+			content:    stringTieGTF,
+			format:     FormatStringTie,
+			readLength: 100,
+			wantCounts: map[string]float64{"G1": 23, "G2": 1},
+			wantTPM:    map[string]float64{"G1": 40, "G2": 60},
+		},
+		{
+			name:       "detected format",
+			content:    stringTieGTF,
+			wantCounts: map[string]float64{"G1": 31, "G2": 1},
+			wantTPM:    map[string]float64{"G1": 40, "G2": 60},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeAbundance(t, "abundance.tsv", tt.content)
+			data, err := LoadAbundanceFile("s1", path, tt.format, tt.readLength)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if data.SampleID != "s1" {
+				t.Errorf("sample ID = %q", data.SampleID)
+			}
+			assertValues(t, "counts", data.Counts, tt.wantCounts)
+			assertValues(t, "TPM", data.Expressions, tt.wantTPM)
+		})
+	}
+}
+
+func assertValues(t *testing.T, label string, got, want map[string]float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", label, got, want)
+		return
+	}
+	for id, value := range want {
+		if math.Abs(got[id]-value) > 1e-6 {
+			t.Errorf("%s[%s] = %v, want %v", label, id, got[id], value)
+		}
+	}
+}
+
+func TestImportAbundanceFilesInvalidInput(t *testing.T) {
+	dir := t.TempDir()
+	gen := NewMatrixGenerator(zap.NewNop())
+
+	bad := writeAbundance(t, "bad.tsv", "gene\tvalue\nG1\t3\n")
+	_, err := gen.ImportAbundanceFiles([]ExternalAbundance{{SampleID: "s1", Path: bad}}, ImportOptions{
+		TPMFile: filepath.Join(dir, "tpm.tsv"),
+	})
+	if !errors.Is(err, ErrInvalidAbundance) {
+		t.Errorf("unrecognized file: expected ErrInvalidAbundance, got %v", err)
+	}
+
+	// An unwritable output is a server failure, not an invalid input
+	good := writeAbundance(t, "quant.sf", "Name\tLength\tEffectiveLength\tTPM\tNumReads\nT1\t100\t80\t1.5\t5\n")
+	blocker := writeAbundance(t, "blocker", "")
+	_, err = gen.ImportAbundanceFiles([]ExternalAbundance{{SampleID: "s1", Path: good}}, ImportOptions{
+		TPMFile: filepath.Join(blocker, "tpm.tsv"),
+	})
+	if err == nil || errors.Is(err, ErrInvalidAbundance) {
+		t.Errorf("unwritable output: expected a non-input error, got %v", err)
+	}
+}
@@ -33,6 +33,7 @@ type TranscriptExpression struct {
 type SampleData struct {
 	SampleID    string
 	Expressions map[string]float64 // TranscriptID -> TPM
+	Counts      map[string]float64 // TranscriptID -> estimated counts
 }
 
 // GenerateTPMMatrix generates a TPM matrix file from multiple Kallisto outputs.
@@ -128,50 +129,7 @@ func (m *MatrixGenerator) GenerateSingleSampleMatrix(sampleID, abundanceDir, out
 
 // loadAbundance loads abundance.tsv from a Kallisto output directory.
 func (m *MatrixGenerator) loadAbundance(sampleID, dir string) (*SampleData, error) {
-	abundancePath := filepath.Join(dir, "abundance.tsv")
-	
-	file, err := os.Open(abundancePath)
-	if err != nil {
-		return nil, fmt.Errorf("opening abundance file: %w", err)
-	}
-	defer file.Close()
-
-	data := &SampleData{
-		SampleID:    sampleID,
-		Expressions: make(map[string]float64),
-	}
-
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-
-		// Skip header
-		if lineNum == 1 {
-			continue
-		}
-
-		fields := strings.Split(line, "\t")
-		if len(fields) < 5 {
-			continue
-		}
-
-		transcriptID := fields[0]
-		tpm, err := strconv.ParseFloat(fields[4], 64)
-		if err != nil {
-			continue
-		}
-
-		data.Expressions[transcriptID] = tpm
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading abundance file: %w", err)
-	}
-
-	return data, nil
+	return LoadAbundanceFile(sampleID, filepath.Join(dir, "abundance.tsv"), FormatKallisto, 0)
 }
 
 // writeMatrix writes the expression matrix to a file.
@@ -227,3 +185,151 @@ func (m *MatrixGenerator) MergeAbundanceFiles(abundanceFiles map[string]string,
 
 	return m.GenerateTPMMatrix(sampleDirs, outputFile)
 }
+
+// ExternalAbundance describes an abundance file produced outside Pandora.
+type ExternalAbundance struct {
+	SampleID string          `json:"sample_id"`
+	Path     string          `json:"path"`
+	Format   AbundanceFormat `json:"format,omitempty"` // detected when empty
+}
+
+// ImportOptions configures an import of external abundance files.
+type ImportOptions struct {
+	TPMFile       string // TPM matrix output
+	CountsFile    string // Counts matrix output (optional)
+	StripVersions bool   // Drop ".N" version suffixes from feature IDs
+	ReadLength    int    // Read length for StringTie counts (DefaultReadLength when zero)
+}
+
+// ImportSummary describes an import of external abundance files.
+type ImportSummary struct {
+	TPMFile        string                     `json:"tpm_file"`
+	CountsFile     string                     `json:"counts_file,omitempty"`
+	Samples        int                        `json:"samples"`
+	Features       int                        `json:"features"`
+	SharedFeatures int                        `json:"shared_features"`
+	Formats        map[string]AbundanceFormat `json:"formats"`
+}
+
+// ImportAbundanceFiles loads abundance files from external quantifiers
+// (Kallisto, Salmon, featureCounts, StringTie) and writes TPM and counts
+// matrices in the same layout as GenerateTPMMatrix, so they can be combined
+// with Pandora samples for differential expression.
+func (m *MatrixGenerator) ImportAbundanceFiles(files []ExternalAbundance, opts ImportOptions) (*ImportSummary, error) {
+	m.logger.Info("importing external abundance files",
+		zap.Int("files", len(files)),
+		zap.String("output", opts.TPMFile),
+	)
+
+	summary := &ImportSummary{
+		TPMFile:    opts.TPMFile,
+		CountsFile: opts.CountsFile,
+		Formats:    make(map[string]AbundanceFormat),
+	}
+
+	samples := make([]*SampleData, 0, len(files))
+	occurrences := make(map[string]int)
+	for _, file := range files {
+		format := file.Format
+		if format == "" {
+			detected, err := DetectAbundanceFormat(file.Path)
+			if err != nil {
+				return nil, fmt.Errorf("%w: sample %s: %w", ErrInvalidAbundance, file.SampleID, err)
+			}
+			format = detected
+		}
+
+		data, err := LoadAbundanceFile(file.SampleID, file.Path, format, opts.ReadLength)
+		if err != nil {
+			return nil, fmt.Errorf("%w: sample %s: %w", ErrInvalidAbundance, file.SampleID, err)
+		}
+		if len(data.Expressions) == 0 {
+			return nil, fmt.Errorf("%w: sample %s: no features read from %s", ErrInvalidAbundance, file.SampleID, file.Path)
+		}
+		if opts.StripVersions {
+			data = stripFeatureVersions(data)
+		}
+
+		samples = append(samples, data)
+		summary.Formats[file.SampleID] = format
+		for id := range data.Expressions {
+			occurrences[id]++
+		}
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("%w: no abundance files given", ErrInvalidAbundance)
+	}
+
+	featureIDs := make([]string, 0, len(occurrences))
+	for id, n := range occurrences {
+		featureIDs = append(featureIDs, id)
+		if n == len(samples) {
+			summary.SharedFeatures++
+		}
+	}
+	sort.Strings(featureIDs)
+	summary.Features = len(featureIDs)
+	summary.Samples = len(samples)
+
+	if summary.SharedFeatures < summary.Features/2 {
+		m.logger.Warn("imported samples share few features, check that they use the same annotation",
+			zap.Int("features", summary.Features),
+			zap.Int("shared", summary.SharedFeatures),
+		)
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].SampleID < samples[j].SampleID
+	})
+
+	if err := m.writeMatrix(featureIDs, samples, opts.TPMFile); err != nil {
+		return nil, fmt.Errorf("writing TPM matrix: %w", err)
+	}
+
+	if opts.CountsFile != "" {
+		counts := make([]*SampleData, len(samples))
+		for i, sample := range samples {
+			counts[i] = &SampleData{SampleID: sample.SampleID, Expressions: sample.Counts}
+		}
+		if err := m.writeMatrix(featureIDs, counts, opts.CountsFile); err != nil {
+			return nil, fmt.Errorf("writing counts matrix: %w", err)
+		}
+	}
+
+	m.logger.Info("external abundance files imported",
+		zap.Int("samples", summary.Samples),
+		zap.Int("features", summary.Features),
+		zap.Int("shared_features", summary.SharedFeatures),
+	)
+
+	return summary, nil
+}
+
+// stripFeatureVersions drops version suffixes (ENST00000456328.2 -> ENST00000456328),
+// summing values of IDs that collapse together.
+func stripFeatureVersions(data *SampleData) *SampleData {
+	stripped := &SampleData{
+		SampleID:    data.SampleID,
+		Expressions: make(map[string]float64, len(data.Expressions)),
+		Counts:      make(map[string]float64, len(data.Counts)),
+	}
+	for id, value := range data.Expressions {
+		stripped.Expressions[StripVersion(id)] += value
+	}
+	for id, value := range data.Counts {
+		stripped.Counts[StripVersion(id)] += value
+	}
+	return stripped
+}
+
+// StripVersion drops a numeric version suffix from a transcript or gene ID
+// (ENST00000456328.2 -> ENST00000456328).
+func StripVersion(id string) string {
+	if i := strings.LastIndex(id, "."); i > 0 {
+		if _, err := strconv.Atoi(id[i+1:]); err == nil {
+			return id[:i]
+		}
+	}
+	return id
+}
@@ -0,0 +1,305 @@
+package quantify
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Subsample is a random fraction of a FASTQ library.
+type Subsample struct {
+	Fraction float64 `json:"fraction"`
+	Reads1   string  `json:"reads1"`
+	Reads2   string  `json:"reads2,omitempty"`
+	Reads    int64   `json:"reads"` // Reads (pairs for paired-end) kept
+}
+
+// SubsampleOptions configures FASTQ subsampling.
+type SubsampleOptions struct {
+	Reads1    string // Forward reads or single-end
+	Reads2    string // Reverse reads (empty for single-end)
+	OutputDir string
+	Fractions []float64 // Fractions in (0, 1]; 1 reuses the input files
+	Seed      int64
+}
+
+// SubsampleFASTQ draws nested random subsamples of a library in a single
+// pass: every read gets one uniform draw and is kept by each fraction above
+// it, so smaller subsamples are subsets of larger ones. Mates of a pair are
+// kept or dropped together. Results are ordered by increasing fraction.
+func SubsampleFASTQ(ctx context.Context, opts SubsampleOptions) ([]Subsample, int64, error) {
+	var fractions []float64
+	sorted := append([]float64(nil), opts.Fractions...)
+	sort.Float64s(sorted)
+	for i, f := range sorted {
+		if i == 0 || f != sorted[i-1] {
+			fractions = append(fractions, f)
+		}
+	}
+	for _, f := range fractions {
+		if f <= 0 || f > 1 {
+			return nil, 0, fmt.Errorf("fraction must be in (0, 1]: %g", f)
+		}
+	}
+	if len(fractions) == 0 {
+		return nil, 0, fmt.Errorf("at least one fraction is required")
+	}
+	if err := CheckFractionLabels(fractions); err != nil {
+		return nil, 0, err
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, 0, err
+	}
+
+	in1, err := openFASTQ(opts.Reads1)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer in1.Close()
+
+	var in2 *fastqReader
+	if opts.Reads2 != "" {
+		in2, err = openFASTQ(opts.Reads2)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer in2.Close()
+	}
+
+	subsamples := make([]Subsample, len(fractions))
+	var writers []*fastqWriter
+	defer func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}()
+
+	// Writers only exist for fractions below 1; the full library is the input itself
+	type output struct {
+		index    int
+		fraction float64
+		w1, w2   *fastqWriter
+	}
+	var outputs []output
+	for i, f := range fractions {
+		subsamples[i].Fraction = f
+		if f == 1 {
+			subsamples[i].Reads1 = opts.Reads1
+			subsamples[i].Reads2 = opts.Reads2
+			continue
+		}
+
+		out := output{index: i, fraction: f}
+		name := filepath.Join(opts.OutputDir, "sub_"+FractionLabel(f))
+		if in2 == nil {
+			subsamples[i].Reads1 = name + ".fastq.gz"
+		} else {
+			subsamples[i].Reads1 = name + "_1.fastq.gz"
+			subsamples[i].Reads2 = name + "_2.fastq.gz"
+		}
+
+		if out.w1, err = createFASTQ(subsamples[i].Reads1); err != nil {
+			return nil, 0, err
+		}
+		writers = append(writers, out.w1)
+		if in2 != nil {
+			if out.w2, err = createFASTQ(subsamples[i].Reads2); err != nil {
+				return nil, 0, err
+			}
+			writers = append(writers, out.w2)
+		}
+		outputs = append(outputs, out)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var total int64
+	for {
+		if total%100000 == 0 && ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+
+		rec1, err := in1.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("reading %s: %w", opts.Reads1, err)
+		}
+		var rec2 []byte
+		if in2 != nil {
+			if rec2, err = in2.next(); err != nil {
+				if err == io.EOF {
+					err = fmt.Errorf("fewer reads than %s", filepath.Base(opts.Reads1))
+				}
+				return nil, 0, fmt.Errorf("reading %s: %w", opts.Reads2, err)
+			}
+		}
+		total++
+
+		draw := rng.Float64()
+		for _, out := range outputs {
+			if draw >= out.fraction {
+				continue
+			}
+			if _, err := out.w1.Write(rec1); err != nil {
+				return nil, 0, err
+			}
+			if out.w2 != nil {
+				if _, err := out.w2.Write(rec2); err != nil {
+					return nil, 0, err
+				}
+			}
+			subsamples[out.index].Reads++
+		}
+	}
+
+	if in2 != nil {
+		if _, err := in2.next(); err != io.EOF {
+			if err == nil {
+				err = fmt.Errorf("more reads than %s", filepath.Base(opts.Reads1))
+			}
+			return nil, 0, fmt.Errorf("reading %s: %w", opts.Reads2, err)
+		}
+	}
+
+	for _, w := range writers {
+		if err := w.Close(); err != nil {
+			return nil, 0, err
+		}
+	}
+	writers = nil
+
+	for i := range subsamples {
+		if subsamples[i].Fraction == 1 {
+			subsamples[i].Reads = total
+		}
+	}
+
+	return subsamples, total, nil
+}
+
+// FractionLabel names the files of a subsample after its fraction in
+// per-mille (0.25 is "250").
+func FractionLabel(f float64) string {
+	return fmt.Sprintf("%03d", int(f*1000+0.5))
+}
+
+// CheckFractionLabels rejects sorted fractions whose files would share a name.
+func CheckFractionLabels(fractions []float64) error {
+	for i, f := range fractions {
+		if FractionLabel(f) == "000" {
+			return fmt.Errorf("fraction must be at least 0.001: %g", f)
+		}
+		if i > 0 && FractionLabel(f) == FractionLabel(fractions[i-1]) {
+			return fmt.Errorf("fractions %g and %g differ by less than 0.001", fractions[i-1], f)
+		}
+	}
+	return nil
+}
+
+// fastqReader reads four-line FASTQ records from a plain or gzipped file.
+type fastqReader struct {
+	file   *os.File
+	gz     *gzip.Reader
+	reader *bufio.Reader
+	record []byte
+}
+
+func openFASTQ(path string) (*fastqReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &fastqReader{file: file}
+	var src io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		r.gz, err = gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("opening %s: %w", path, err)
+		}
+		src = r.gz
+	}
+	r.reader = bufio.NewReaderSize(src, 256*1024)
+	return r, nil
+}
+
+// next returns the next record including its line breaks. The slice is
+// reused by the following call.
+func (r *fastqReader) next() ([]byte, error) {
+	r.record = r.record[:0]
+	for i := 0; i < 4; i++ {
+		line, err := r.reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			return nil, fmt.Errorf("FASTQ line too long")
+		}
+		if err == io.EOF {
+			if i == 0 && len(line) == 0 {
+				return nil, io.EOF
+			}
+			if len(line) == 0 {
+				return nil, fmt.Errorf("truncated FASTQ record")
+			}
+			line = append(line, '\n')
+		} else if err != nil {
+			return nil, err
+		}
+		if i == 0 && line[0] != '@' {
+			return nil, fmt.Errorf("malformed FASTQ record")
+		}
+		r.record = append(r.record, line...)
+	}
+	return r.record, nil
+}
+
+func (r *fastqReader) Close() error {
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	return r.file.Close()
+}
+
+// fastqWriter writes gzipped FASTQ.
+type fastqWriter struct {
+	file   *os.File
+	gz     *gzip.Writer
+	writer *bufio.Writer
+	closed bool
+}
+
+func createFASTQ(path string) (*fastqWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, _ := gzip.NewWriterLevel(file, gzip.BestSpeed)
+	return &fastqWriter{file: file, gz: gz, writer: bufio.NewWriterSize(gz, 256*1024)}, nil
+}
+
+func (w *fastqWriter) Write(p []byte) (int, error) {
+	return w.writer.Write(p)
+}
+
+func (w *fastqWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	err := w.writer.Flush()
+	if gzErr := w.gz.Close(); err == nil {
+		err = gzErr
+	}
+	if fileErr := w.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package quantify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFASTQ(t *testing.T, path string, reads int, mate int) {
+	t.Helper()
+	var b strings.Builder
+	for i := 0; i < reads; i++ {
+		fmt.Fprintf(&b, "@r%d/%d\nACGT\n+\nIIII\n", i, mate)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readNames returns the read names of a FASTQ file without the mate suffix.
+func readNames(t *testing.T, path string) []string {
+	t.Helper()
+	in, err := openFASTQ(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	var names []string
+	for {
+		rec, err := in.next()
+		if err != nil {
+			break
+		}
+		header, _, _ := strings.Cut(string(rec), "\n")
+		name, _, _ := strings.Cut(header, "/")
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestSubsampleFASTQ(t *testing.T) {
+	tests := []struct {
+		name      string
+		reads1    int
+		reads2    int // -1 for single-end
+		fractions []float64
+		wantErr   string
+	}{
+		{name: "paired-end", reads1: 2000, reads2: 2000, fractions: []float64{0.5, 0.1, 1, 0.25}},
+		{name: "single-end", reads1: 2000, reads2: -1, fractions: []float64{0.3, 0.6}},
+		{name: "fewer reverse reads", reads1: 100, reads2: 99, fractions: []float64{0.5}, wantErr: "fewer reads"},
+		{name: "more reverse reads", reads1: 100, reads2: 101, fractions: []float64{0.5}, wantErr: "more reads"},
+		{name: "colliding labels", reads1: 10, reads2: -1, fractions: []float64{0.1, 0.1004}, wantErr: "differ by less than 0.001"},
+		{name: "fraction too small", reads1: 10, reads2: -1, fractions: []float64{0.0001}, wantErr: "at least 0.001"},
+		{name: "fraction out of range", reads1: 10, reads2: -1, fractions: []float64{1.5}, wantErr: "(0, 1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := SubsampleOptions{
+				Reads1:    filepath.Join(dir, "in_1.fastq"),
+				OutputDir: filepath.Join(dir, "out"),
+				Fractions: tt.fractions,
+				Seed:      42,
+			}
+			writeFASTQ(t, opts.Reads1, tt.reads1, 1)
+			if tt.reads2 >= 0 {
+				opts.Reads2 = filepath.Join(dir, "in_2.fastq")
+				writeFASTQ(t, opts.Reads2, tt.reads2, 2)
+			}
+
+			subsamples, total, err := SubsampleFASTQ(context.Background(), opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != int64(tt.reads1) {
+				t.Errorf("total = %d, want %d", total, tt.reads1)
+			}
+
+			var previous map[string]bool
+			for i, sub := range subsamples {
+				if i > 0 && sub.Fraction <= subsamples[i-1].Fraction {
+					t.Fatalf("subsamples not ordered by fraction: %g after %g", sub.Fraction, subsamples[i-1].Fraction)
+				}
+
+				names := readNames(t, sub.Reads1)
+				if int64(len(names)) != sub.Reads {
+					t.Errorf("fraction %g: %d reads written, reported %d", sub.Fraction, len(names), sub.Reads)
+				}
+				if opts.Reads2 != "" {
+					mates := readNames(t, sub.Reads2)
+					if strings.Join(mates, ",") != strings.Join(names, ",") {
+						t.Errorf("fraction %g: mates are not paired", sub.Fraction)
+					}
+				}
+
+				// Each subsample must contain every read of the smaller ones
+				current := make(map[string]bool, len(names))
+				for _, name := range names {
+					current[name] = true
+				}
+				for name := range previous {
+					if !current[name] {
+						t.Errorf("fraction %g misses read %s of a smaller fraction", sub.Fraction, name)
+						break
+					}
+				}
+				previous = current
+
+				expected := sub.Fraction * float64(tt.reads1)
+				if diff := float64(sub.Reads) - expected; diff > 0.2*expected || diff < -0.2*expected {
+					t.Errorf("fraction %g kept %d reads, expected about %.0f", sub.Fraction, sub.Reads, expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package reference
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/models"
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/quantify"
+	"go.uber.org/zap"
+)
+
+// annotationDownloadTimeout bounds the transcriptome download used to extract
+// a missing annotation table.
+const annotationDownloadTimeout = 30 * time.Minute
+
+// Failed extractions are retried after a backoff that doubles per failure.
+const (
+	annotationRetryBase = 5 * time.Minute
+	annotationRetryMax  = 6 * time.Hour
+)
+
+// ErrAnnotationPending is returned when the caller stops waiting for an
+// annotation table that is still being extracted.
+var ErrAnnotationPending = errors.New("gene annotation is still being extracted")
+
+// annotationExtraction tracks the background extraction of an annotation
+// table. err and retryAt are set before done is closed.
+type annotationExtraction struct {
+	done     chan struct{}
+	err      error
+	failures int
+	retryAt  time.Time
+}
+
+// GeneAnnotation holds the symbol and description of a gene.
+type GeneAnnotation struct {
+	GeneID      string `json:"gene_id"`
+	Symbol      string `json:"symbol"`
+	Description string `json:"description,omitempty"`
+}
+
+// Annotation maps gene and transcript IDs to gene annotations. Lookups
+// ignore version suffixes (ENST00000456328.2 matches ENST00000456328).
+type Annotation struct {
+	entries map[string]*GeneAnnotation
+}
+
+// Lookup returns the annotation of a gene or transcript ID.
+func (a *Annotation) Lookup(id string) (*GeneAnnotation, bool) {
+	if gene, ok := a.entries[id]; ok {
+		return gene, true
+	}
+	gene, ok := a.entries[quantify.StripVersion(id)]
+	return gene, ok
+}
+
+// Len returns the number of annotated IDs.
+func (a *Annotation) Len() int {
+	return len(a.entries)
+}
+
+func (a *Annotation) add(id string, gene *GeneAnnotation) {
+	if id == "" || gene.Symbol == "" {
+		return
+	}
+	a.entries[id] = gene
+	if stripped := quantify.StripVersion(id); stripped != id {
+		a.entries[stripped] = gene
+	}
+}
+
+// annotationTableFile returns the ID mapping table written when an index is built.
+func (m *Manager) annotationTableFile(org *OrganismInfo) string {
+	return filepath.Join(m.referenceDir, org.Name+"_annotation.tsv")
+}
+
+// annotationGTFFile returns the path where a user-provided GTF is looked up.
+func (m *Manager) annotationGTFFile(org *OrganismInfo) string {
+	return filepath.Join(m.referenceDir, org.Name+".gtf")
+}
+
+// Annotation returns the gene annotation of an organism. A GTF placed at
+// <reference_dir>/<organism>.gtf takes precedence over the ID mapping table
+// extracted from the transcriptome when the index was built. Indices built
+// before annotations existed have no table; it is then extracted in the
+// background from a fresh download of the transcriptome, and Annotation waits
+// for it until ctx is done (ErrAnnotationPending).
+func (m *Manager) Annotation(ctx context.Context, organism string) (*Annotation, error) {
+	org, found := m.GetOrganism(organism)
+	if !found {
+		return nil, fmt.Errorf("organism not found: %s", organism)
+	}
+
+	m.mu.RLock()
+	cached, ok := m.annotations[org.Name]
+	m.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	var annotation *Annotation
+	var err error
+	if gtfPath := m.annotationGTFFile(org); fileExists(gtfPath) {
+		annotation, err = loadGTF(gtfPath)
+	} else if tablePath := m.annotationTableFile(org); fileExists(tablePath) {
+		annotation, err = loadAnnotationTable(tablePath)
+	} else if org.Available && org.TranscriptURL != "" {
+		if err = m.extractAnnotation(ctx, org); err == nil {
+			annotation, err = loadAnnotationTable(tablePath)
+		}
+	} else {
+		return nil, fmt.Errorf("no annotation available for %s", organism)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading annotation for %s: %w", organism, err)
+	}
+
+	m.mu.Lock()
+	m.annotations[org.Name] = annotation
+	m.mu.Unlock()
+
+	m.logger.Info("gene annotation loaded",
+		zap.String("organism", org.Name),
+		zap.Int("ids", annotation.Len()),
+	)
+
+	return annotation, nil
+}
+
+// AnnotateGenes fills in missing gene names and descriptions of DE genes and
+// returns how many genes were annotated.
+func (m *Manager) AnnotateGenes(ctx context.Context, organism string, genes []models.DEGene) (int, error) {
+	annotation, err := m.Annotation(ctx, organism)
+	if err != nil {
+		return 0, err
+	}
+
+	annotated := 0
+	for i := range genes {
+		gene, ok := annotation.Lookup(genes[i].GeneID)
+		if !ok {
+			continue
+		}
+		if genes[i].GeneName == "" {
+			genes[i].GeneName = gene.Symbol
+		}
+		if genes[i].Description == "" {
+			genes[i].Description = gene.Description
+		}
+		annotated++
+	}
+
+	return annotated, nil
+}
+
+// extractAnnotation starts the background extraction of the annotation table
+// of an organism unless one is running or failed recently, and waits for it
+// until ctx is done. Concurrent callers share a single download.
+func (m *Manager) extractAnnotation(ctx context.Context, org *OrganismInfo) error {
+	m.mu.Lock()
+	extraction, ok := m.extractions[org.Name]
+	if ok && extraction.err != nil && time.Now().Before(extraction.retryAt) {
+		m.mu.Unlock()
+		return fmt.Errorf("extraction failed, retrying after %s: %w",
+			extraction.retryAt.Format(time.RFC3339), extraction.err)
+	}
+	if !ok || extraction.err != nil {
+		failures := 0
+		if ok {
+			failures = extraction.failures
+		}
+		extraction = &annotationExtraction{done: make(chan struct{}), failures: failures}
+		m.extractions[org.Name] = extraction
+		go m.runAnnotationExtraction(org, extraction)
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-extraction.done:
+		return extraction.err
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %s", ErrAnnotationPending, org.Name)
+	}
+}
+
+// runAnnotationExtraction extracts an annotation table and records the
+// outcome. Failures are remembered until their backoff expires.
+func (m *Manager) runAnnotationExtraction(org *OrganismInfo, extraction *annotationExtraction) {
+	ctx, cancel := context.WithTimeout(m.ctx, annotationDownloadTimeout)
+	defer cancel()
+
+	err := m.generateAnnotationTable(ctx, org)
+
+	m.mu.Lock()
+	if err != nil {
+		extraction.failures++
+		backoff := annotationRetryBase << (extraction.failures - 1)
+		if backoff > annotationRetryMax || backoff <= 0 {
+			backoff = annotationRetryMax
+		}
+		extraction.err = err
+		extraction.retryAt = time.Now().Add(backoff)
+		m.logger.Warn("annotation extraction failed",
+			zap.String("organism", org.Name),
+			zap.Int("failures", extraction.failures),
+			zap.Duration("retry_in", backoff),
+			zap.Error(err),
+		)
+	} else {
+		delete(m.extractions, org.Name)
+	}
+	m.mu.Unlock()
+
+	close(extraction.done)
+}
+
+// generateAnnotationTable downloads the transcriptome of an organism whose
+// index already exists and extracts its annotation table.
+func (m *Manager) generateAnnotationTable(ctx context.Context, org *OrganismInfo) error {
+	if fileExists(m.annotationTableFile(org)) {
+		return nil
+	}
+
+	m.logger.Info("extracting missing gene annotation", zap.String("organism", org.Name))
+
+	fastaPath := filepath.Join(m.referenceDir, org.Name+"_annotation_rna.fna.gz")
+	defer os.Remove(fastaPath)
+	if err := m.downloadFile(ctx, org.TranscriptURL, fastaPath); err != nil {
+		return fmt.Errorf("downloading transcriptome: %w", err)
+	}
+	return m.writeAnnotationTable(org, fastaPath)
+}
+
+// writeAnnotationTable extracts transcript-to-gene annotations from the
+// headers of a plain or gzipped transcriptome FASTA into a TSV table.
+func (m *Manager) writeAnnotationTable(org *OrganismInfo, fastaPath string) error {
+	in, closeIn, err := openMaybeGzip(fastaPath)
+	if err != nil {
+		return err
+	}
+	defer closeIn()
+
+	// Write to a temporary file so a failed extraction never leaves a partial table
+	tablePath := m.annotationTableFile(org)
+	out, err := os.Create(tablePath + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	writer := bufio.NewWriter(out)
+	writer.WriteString("transcript_id\tgene_id\tsymbol\tdescription\n")
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	rows := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, ">") {
+			continue
+		}
+		transcriptID, gene := parseFASTAHeader(line)
+		if gene.Symbol == "" {
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", transcriptID, gene.GeneID, gene.Symbol, gene.Description)
+		rows++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(out.Name(), tablePath); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	delete(m.annotations, org.Name)
+	m.mu.Unlock()
+
+	m.logger.Info("annotation table written",
+		zap.String("organism", org.Name),
+		zap.Int("transcripts", rows),
+	)
+	return nil
+}
+
+// ncbiSymbolPattern captures the gene symbol of RefSeq RNA headers, e.g.
+// "... uncharacterized LOC110371234 (LOC110371234), mRNA".
+var ncbiSymbolPattern = regexp.MustCompile(`^(.*) \(([^()\s]+)\)(, .*)?$`)
+
+// parseFASTAHeader extracts the transcript ID and gene annotation from an
+// Ensembl cDNA header (gene:/gene_symbol:/description: tags) or a RefSeq
+// RNA header (description followed by the symbol in parentheses).
+func parseFASTAHeader(line string) (string, GeneAnnotation) {
+	line = strings.TrimPrefix(line, ">")
+	transcriptID, rest, _ := strings.Cut(line, " ")
+	var gene GeneAnnotation
+
+	if strings.Contains(rest, "gene_symbol:") || strings.Contains(rest, " gene:") {
+		if desc, _, found := strings.Cut(rest, "description:"); found {
+			gene.Description = strings.TrimSpace(cleanDescription(rest[len(desc)+len("description:"):]))
+			rest = desc
+		}
+		for _, field := range strings.Fields(rest) {
+			key, value, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "gene":
+				gene.GeneID = value
+			case "gene_symbol":
+				gene.Symbol = value
+			}
+		}
+		if gene.Symbol == "" {
+			gene.Symbol = gene.GeneID
+		}
+		return transcriptID, gene
+	}
+
+	if match := ncbiSymbolPattern.FindStringSubmatch(rest); match != nil {
+		gene.Symbol = match[2]
+		gene.GeneID = match[2]
+		gene.Description = strings.TrimPrefix(match[1], "PREDICTED: ")
+	}
+	return transcriptID, gene
+}
+
+// cleanDescription drops the trailing "[Source:...]" of Ensembl descriptions.
+func cleanDescription(desc string) string {
+	if i := strings.Index(desc, " [Source:"); i >= 0 {
+		return desc[:i]
+	}
+	return desc
+}
+
+// loadAnnotationTable loads a table written by writeAnnotationTable.
+func loadAnnotationTable(path string) (*Annotation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	annotation := &Annotation{entries: make(map[string]*GeneAnnotation)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		// Skip header
+		if lineNum == 1 {
+			continue
+		}
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		gene := &GeneAnnotation{GeneID: fields[1], Symbol: fields[2], Description: fields[3]}
+		annotation.add(fields[0], gene)
+		annotation.add(fields[1], gene)
+	}
+	return annotation, scanner.Err()
+}
+
+// loadGTF loads gene annotations from a GTF file (Ensembl or RefSeq style).
+func loadGTF(path string) (*Annotation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseGTF(file)
+}
+
+func parseGTF(r io.Reader) (*Annotation, error) {
+	annotation := &Annotation{entries: make(map[string]*GeneAnnotation)}
+	genes := make(map[string]*GeneAnnotation)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 9 || (fields[2] != "gene" && fields[2] != "transcript") {
+			continue
+		}
+
+		attrs := parseGTFAttributes(fields[8])
+		geneID := attrs["gene_id"]
+		if geneID == "" {
+			continue
+		}
+
+		gene, ok := genes[geneID]
+		if !ok {
+			gene = &GeneAnnotation{GeneID: geneID}
+			genes[geneID] = gene
+		}
+		if gene.Symbol == "" {
+			gene.Symbol = firstNonEmpty(attrs["gene_name"], attrs["gene"], attrs["gene_symbol"], geneID)
+		}
+		if gene.Description == "" {
+			gene.Description = firstNonEmpty(attrs["description"], attrs["product"])
+		}
+
+		annotation.add(geneID, gene)
+		annotation.add(attrs["transcript_id"], gene)
+	}
+
+	return annotation, scanner.Err()
+}
+
+// parseGTFAttributes parses the attribute column (key "value"; key "value";).
+func parseGTFAttributes(column string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(column, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), " ")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(strings.TrimSpace(value)); err == nil {
+			value = unquoted
+		}
+		if _, exists := attrs[key]; !exists {
+			attrs[key] = value
+		}
+	}
+	return attrs
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,298 @@
+package reference
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guidiju-50/pandora/ANALYSIS/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestParseFASTAHeader(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		transcriptID string
+		want         GeneAnnotation
+	}{
+		{
+			name:         "Ensembl cDNA",
+			header:       ">ENST00000456328.2 cdna chromosome:GRCh38:1:11869:14409:1 gene:ENSG00000290825.1 gene_biotype:lncRNA transcript_biotype:lncRNA gene_symbol:DDX11L2 description:DEAD/H-box helicase 11 like 2 (pseudogene) [Source:HGNC Symbol;Acc:HGNC:37102]",
+			transcriptID: "ENST00000456328.2",
+			want:         GeneAnnotation{GeneID: "ENSG00000290825.1", Symbol: "DDX11L2", Description: "DEAD/H-box helicase 11 like 2 (pseudogene)"},
+		},
+		{
+			name:         "Ensembl without symbol",
+			header:       ">ENST00000000001.1 cdna chromosome:GRCh38:1:1:10:1 gene:ENSG00000000001.1 gene_biotype:protein_coding",
+			transcriptID: "ENST00000000001.1",
+			want:         GeneAnnotation{GeneID: "ENSG00000000001.1", Symbol: "ENSG00000000001.1"},
+		},
+		{
+			name:         "RefSeq",
+			header:       ">XM_021329887.2 PREDICTED: Helicoverpa armigera uncharacterized LOC110371234 (LOC110371234), mRNA",
+			transcriptID: "XM_021329887.2",
+			want:         GeneAnnotation{GeneID: "LOC110371234", Symbol: "LOC110371234", Description: "Helicoverpa armigera uncharacterized LOC110371234"},
+		},
+		{
+			name:         "RefSeq without trailing molecule type",
+			header:       ">NM_000546.6 Homo sapiens tumor protein p53 (TP53)",
+			transcriptID: "NM_000546.6",
+			want:         GeneAnnotation{GeneID: "TP53", Symbol: "TP53", Description: "Homo sapiens tumor protein p53"},
+		},
+		{
+			name:         "unannotated",
+			header:       ">contig_1 length=1500",
+			transcriptID: "contig_1",
+		},
+		{
+			name:         "ID only",
+			header:       ">contig_2",
+			transcriptID: "contig_2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transcriptID, gene := parseFASTAHeader(tt.header)
+			if transcriptID != tt.transcriptID {
+				t.Errorf("transcript ID = %q, want %q", transcriptID, tt.transcriptID)
+			}
+			if gene != tt.want {
+				t.Errorf("gene = %+v, want %+v", gene, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGTFAttributes(t *testing.T) {
+	attrs := parseGTFAttributes(`gene_id "ENSG1"; transcript_id "ENST1.2"; gene_name "ABC1"; tag "basic"; tag "CCDS"; level 2; description "kinase; putative"`)
+
+	want := map[string]string{
+		"gene_id":       "ENSG1",
+		"transcript_id": "ENST1.2",
+		"gene_name":     "ABC1",
+		"tag":           "basic", // First value wins
+		"level":         "2",
+	}
+	for key, value := range want {
+		if attrs[key] != value {
+			t.Errorf("%s = %q, want %q", key, attrs[key], value)
+		}
+	}
+}
+
+func TestParseGTF(t *testing.T) {
+	gtf := strings.Join([]string{
+		"#!genome-build GRCh38",
+		"1\tensembl\tgene\t100\t900\t.\t+\t.\tgene_id \"ENSG1\"; gene_version \"3\"; gene_name \"ABC1\";",
+		"1\tensembl\ttranscript\t100\t900\t.\t+\t.\tgene_id \"ENSG1\"; transcript_id \"ENST1.2\"; gene_name \"ABC1\";",
+		"1\tensembl\texon\t100\t200\t.\t+\t.\tgene_id \"ENSG1\"; transcript_id \"ENST1.2\"; exon_number \"1\";",
+		"1\tensembl\ttranscript\t1000\t1500\t.\t-\t.\tgene_id \"ENSG2\"; transcript_id \"ENST2\";",
+		"NC_1\tGnomon\tgene\t10\t90\t.\t+\t.\tgene_id \"LOC42\"; gene \"LOC42\"; description \"uncharacterized protein\";",
+		"NC_1\tGnomon\ttranscript\t10\t90\t.\t+\t.\tgene_id \"LOC42\"; transcript_id \"XM_1.1\"; product \"uncharacterized protein, transcript X1\";",
+		"malformed line",
+	}, "\n")
+
+	annotation, err := parseGTF(strings.NewReader(gtf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id          string
+		symbol      string
+		description string
+	}{
+		{"ENSG1", "ABC1", ""},
+		{"ENST1.2", "ABC1", ""},
+		{"ENST1", "ABC1", ""},   // Version stripped
+		{"ENST1.9", "ABC1", ""}, // Any version matches
+		{"ENSG2", "ENSG2", ""},  // Gene ID when no name
+		{"ENST2", "ENSG2", ""},
+		{"LOC42", "LOC42", "uncharacterized protein"},
+		{"XM_1.1", "LOC42", "uncharacterized protein"},
+	}
+	for _, tt := range tests {
+		gene, ok := annotation.Lookup(tt.id)
+		if !ok {
+			t.Errorf("%s not annotated", tt.id)
+			continue
+		}
+		if gene.Symbol != tt.symbol || gene.Description != tt.description {
+			t.Errorf("%s = %+v, want symbol %q description %q", tt.id, gene, tt.symbol, tt.description)
+		}
+	}
+	if _, ok := annotation.Lookup("ENSG3"); ok {
+		t.Error("unknown ID annotated")
+	}
+}
+
+func TestLoadAnnotationTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotation.tsv")
+	writeTestFile(t, path, "transcript_id\tgene_id\tsymbol\tdescription\n"+
+		"ENST1.2\tENSG1\tABC1\tATP binding cassette 1\n"+
+		"XM_1.1\tLOC42\tLOC42\t\n"+
+		"short\trow\n"+
+		"ENST9\tENSG9\t\tno symbol\n", false)
+
+	annotation, err := loadAnnotationTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"ENST1.2", "ENST1", "ENSG1"} {
+		gene, ok := annotation.Lookup(id)
+		if !ok || gene.Symbol != "ABC1" || gene.Description != "ATP binding cassette 1" {
+			t.Errorf("%s = %+v, %v", id, gene, ok)
+		}
+	}
+	if gene, ok := annotation.Lookup("XM_1.1"); !ok || gene.GeneID != "LOC42" {
+		t.Errorf("XM_1.1 = %+v, %v", gene, ok)
+	}
+	// Rows without a symbol and the header are not annotations
+	for _, id := range []string{"ENST9", "ENSG9", "transcript_id", "short"} {
+		if _, ok := annotation.Lookup(id); ok {
+			t.Errorf("%s should not be annotated", id)
+		}
+	}
+}
+
+func TestAnnotateGenes(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(context.Background(), dir, "kallisto", zap.NewNop())
+	writeTestFile(t, filepath.Join(dir, "homo_sapiens.gtf"),
+		"1\tensembl\tgene\t1\t9\t.\t+\t.\tgene_id \"ENSG1\"; gene_name \"ABC1\"; description \"first gene\";\n"+
+			"1\tensembl\tgene\t1\t9\t.\t+\t.\tgene_id \"ENSG2\"; gene_name \"DEF2\";\n", false)
+
+	genes := []models.DEGene{
+		{GeneID: "ENSG1.4"},
+		{GeneID: "ENSG2", GeneName: "kept", Description: "kept too"},
+		{GeneID: "ENSG3"},
+	}
+	annotated, err := m.AnnotateGenes(context.Background(), "Homo sapiens", genes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if annotated != 2 {
+		t.Errorf("annotated = %d, want 2", annotated)
+	}
+	if genes[0].GeneName != "ABC1" || genes[0].Description != "first gene" {
+		t.Errorf("gene 0 = %+v", genes[0])
+	}
+	if genes[1].GeneName != "kept" || genes[1].Description != "kept too" {
+		t.Errorf("existing names overwritten: %+v", genes[1])
+	}
+	if genes[2].GeneName != "" {
+		t.Errorf("unknown gene named: %+v", genes[2])
+	}
+
+	if _, err := m.AnnotateGenes(context.Background(), "unknown_organism", genes); err == nil {
+		t.Error("expected an error for an unknown organism")
+	}
+}
+
+// annotationServer serves a transcriptome FASTA, failing while fail is set and
+// blocking each request until release is closed.
+func annotationServer(t *testing.T, release <-chan struct{}, fail *atomic.Bool, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		if fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(">ENST1.1 cdna gene:ENSG1 gene_symbol:ABC1 description:first gene\nACGT\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newExtractionManager(t *testing.T, transcriptURL string) *Manager {
+	t.Helper()
+	m := NewManager(context.Background(), t.TempDir(), "kallisto", zap.NewNop())
+	m.organisms["test_org"] = &OrganismInfo{Name: "test_org", TranscriptURL: transcriptURL, Available: true}
+	return m
+}
+
+func TestAnnotationExtractionRunsInBackground(t *testing.T) {
+	release := make(chan struct{})
+	var fail atomic.Bool
+	var requests atomic.Int32
+	srv := annotationServer(t, release, &fail, &requests)
+	m := newExtractionManager(t, srv.URL+"/rna.fa")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := m.Annotation(ctx, "test_org"); !errors.Is(err, ErrAnnotationPending) {
+		t.Fatalf("error = %v, want ErrAnnotationPending", err)
+	}
+
+	// The extraction keeps running after the caller gives up
+	close(release)
+	annotation, err := m.Annotation(context.Background(), "test_org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gene, ok := annotation.Lookup("ENST1"); !ok || gene.Symbol != "ABC1" {
+		t.Errorf("ENST1 = %+v, %v", gene, ok)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("transcriptome downloaded %d times, want 1", n)
+	}
+}
+
+func TestAnnotationExtractionBackoff(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	var fail atomic.Bool
+	fail.Store(true)
+	var requests atomic.Int32
+	srv := annotationServer(t, release, &fail, &requests)
+	m := newExtractionManager(t, srv.URL+"/rna.fa")
+
+	if _, err := m.Annotation(context.Background(), "test_org"); err == nil {
+		t.Fatal("expected the download failure")
+	}
+
+	// The failure is remembered until the backoff expires
+	fail.Store(false)
+	_, err := m.Annotation(context.Background(), "test_org")
+	if err == nil || !strings.Contains(err.Error(), "retrying after") {
+		t.Fatalf("error = %v, want cached failure", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("transcriptome downloaded %d times during backoff, want 1", n)
+	}
+
+	m.mu.Lock()
+	extraction := m.extractions["test_org"]
+	if extraction.failures != 1 {
+		t.Errorf("failures = %d, want 1", extraction.failures)
+	}
+	if wait := time.Until(extraction.retryAt); wait <= 0 || wait > annotationRetryBase {
+		t.Errorf("retry in %v, want at most %v", wait, annotationRetryBase)
+	}
+	extraction.retryAt = time.Now().Add(-time.Second)
+	m.mu.Unlock()
+
+	if _, err := m.Annotation(context.Background(), "test_org"); err != nil {
+		t.Fatalf("retry after backoff: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("transcriptome downloaded %d times, want 2", n)
+	}
+	m.mu.RLock()
+	_, pending := m.extractions["test_org"]
+	m.mu.RUnlock()
+	if pending {
+		t.Error("successful extraction still tracked")
+	}
+}
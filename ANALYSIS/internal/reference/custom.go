@@ -0,0 +1,474 @@
+package reference
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// manifestFile lists the custom organisms so they survive restarts.
+const manifestFile = "custom_organisms.json"
+
+// maxValidationErrors bounds the errors reported for an uploaded FASTA.
+const maxValidationErrors = 20
+
+// buildRetention is how long finished index builds can be looked up.
+const buildRetention = 24 * time.Hour
+
+// organismNamePattern restricts organism names, which are used in file names.
+var organismNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// nucleotideCodes lists the IUPAC nucleotide codes accepted in sequences.
+const nucleotideCodes = "ACGTUNRYKMSWBDHVacgtunrykmswbdhv"
+
+// ErrOrganismExists is returned when a custom reference reuses an organism name.
+var ErrOrganismExists = errors.New("organism already exists")
+
+// CustomReference describes an uploaded transcriptome and its optional annotation.
+type CustomReference struct {
+	Name           string `json:"name"`
+	ScientificName string `json:"scientific_name"`
+	TaxID          string `json:"tax_id"`
+	FASTAPath      string `json:"-"` // Plain or gzipped FASTA
+	GTFPath        string `json:"-"` // Optional GTF
+	UploadDir      string `json:"-"` // Removed once the build finishes
+}
+
+// ReferenceValidation reports the checks run on an uploaded reference.
+type ReferenceValidation struct {
+	Sequences   int      `json:"sequences"`
+	TotalLength int64    `json:"total_length"`
+	MinLength   int      `json:"min_length"`
+	MaxLength   int      `json:"max_length"`
+	Annotated   int      `json:"annotated"`             // Sequences with a gene symbol in the header
+	GTFMatched  int      `json:"gtf_matched,omitempty"` // Sequences found in the GTF
+	ErrorCount  int      `json:"error_count"`
+	Errors      []string `json:"errors,omitempty"` // First maxValidationErrors errors
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// Valid reports whether the reference passed validation.
+func (v *ReferenceValidation) Valid() bool {
+	return v.ErrorCount == 0
+}
+
+func (v *ReferenceValidation) errorf(format string, args ...any) {
+	v.ErrorCount++
+	if len(v.Errors) < maxValidationErrors {
+		v.Errors = append(v.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// IndexBuild tracks a server-side index build.
+type IndexBuild struct {
+	ID          string               `json:"id"`
+	Organism    string               `json:"organism"`
+	Status      string               `json:"status"` // running, completed, failed
+	Progress    int                  `json:"progress"`
+	Stage       string               `json:"stage"`
+	Error       string               `json:"error,omitempty"`
+	Validation  *ReferenceValidation `json:"validation,omitempty"`
+	StartedAt   time.Time            `json:"started_at"`
+	CompletedAt *time.Time           `json:"completed_at,omitempty"`
+}
+
+// NormalizeOrganismName converts an organism name to its registry key.
+func NormalizeOrganismName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+}
+
+// ValidateReference checks that a FASTA has unique, non-empty sequence IDs
+// and only nucleotide sequences, and that an optional GTF annotates them.
+func (m *Manager) ValidateReference(ref CustomReference) (*ReferenceValidation, error) {
+	validation := &ReferenceValidation{}
+
+	name := NormalizeOrganismName(ref.Name)
+	if !organismNamePattern.MatchString(name) {
+		validation.errorf("invalid organism name %q: use letters, digits, '_', '.' or '-'", ref.Name)
+	}
+
+	ids, err := validateFASTA(ref.FASTAPath, validation)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.GTFPath != "" {
+		annotation, err := loadGTFMaybeGzip(ref.GTFPath)
+		if err != nil {
+			validation.errorf("GTF: %v", err)
+		} else if annotation.Len() == 0 {
+			validation.errorf("GTF: no gene or transcript records with gene_id found")
+		} else {
+			for _, id := range ids {
+				if _, ok := annotation.Lookup(id); ok {
+					validation.GTFMatched++
+				}
+			}
+			switch {
+			case validation.GTFMatched == 0 && len(ids) > 0:
+				validation.errorf("GTF: no transcript_id matches a FASTA sequence ID")
+			case validation.GTFMatched < len(ids):
+				validation.Warnings = append(validation.Warnings,
+					fmt.Sprintf("GTF annotates %d of %d sequences", validation.GTFMatched, len(ids)))
+			}
+		}
+	}
+
+	if ref.GTFPath == "" && validation.Annotated == 0 && validation.Sequences > 0 {
+		validation.Warnings = append(validation.Warnings,
+			"headers carry no gene symbols and no GTF was provided; DE results will not be annotated")
+	}
+
+	return validation, nil
+}
+
+// validateFASTA checks a FASTA file and returns its sequence IDs.
+func validateFASTA(path string, validation *ReferenceValidation) ([]string, error) {
+	reader, closeFn, err := openMaybeGzip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	seen := make(map[string]int)
+	var ids []string
+	var currentID string
+	currentLen, headerLine := 0, 0
+
+	endRecord := func() {
+		if currentID == "" {
+			return
+		}
+		if currentLen == 0 {
+			validation.errorf("line %d: sequence %q is empty", headerLine, currentID)
+		}
+		validation.Sequences++
+		validation.TotalLength += int64(currentLen)
+		if validation.Sequences == 1 || currentLen < validation.MinLength {
+			validation.MinLength = currentLen
+		}
+		if currentLen > validation.MaxLength {
+			validation.MaxLength = currentLen
+		}
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			endRecord()
+			headerLine = lineNum
+			currentLen = 0
+
+			id, gene := parseFASTAHeader(line)
+			currentID = id
+			if id == "" {
+				validation.errorf("line %d: header has no sequence ID", lineNum)
+				currentID = fmt.Sprintf("<line %d>", lineNum)
+				continue
+			}
+			if first, dup := seen[id]; dup {
+				validation.errorf("line %d: duplicate sequence ID %q (first seen on line %d)", lineNum, id, first)
+				continue
+			}
+			seen[id] = lineNum
+			ids = append(ids, id)
+			if gene.Symbol != "" {
+				validation.Annotated++
+			}
+			continue
+		}
+
+		if currentID == "" {
+			validation.errorf("line %d: sequence data before the first '>' header", lineNum)
+			// Report once; the file is not FASTA
+			return ids, nil
+		}
+
+		if i := strings.IndexFunc(line, func(r rune) bool { return !strings.ContainsRune(nucleotideCodes, r) }); i >= 0 {
+			validation.errorf("line %d: invalid nucleotide %q in sequence %q", lineNum, line[i], currentID)
+		}
+		currentLen += len(line)
+	}
+	if err := scanner.Err(); err != nil {
+		validation.errorf("line %d: %v", lineNum+1, err)
+		return ids, nil
+	}
+	endRecord()
+
+	if validation.Sequences == 0 {
+		validation.errorf("FASTA contains no sequences")
+	}
+	return ids, nil
+}
+
+// StartCustomBuild registers an index build for a validated reference and
+// runs it in the background until it finishes or ctx is cancelled. Track it
+// with GetBuild.
+func (m *Manager) StartCustomBuild(ctx context.Context, ref CustomReference, validation *ReferenceValidation) (*IndexBuild, error) {
+	name := NormalizeOrganismName(ref.Name)
+
+	m.mu.Lock()
+	m.pruneBuilds()
+	if _, exists := m.organisms[name]; exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrOrganismExists, name)
+	}
+	for _, build := range m.builds {
+		if build.Organism == name && build.Status == "running" {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("%w: %s is already being built", ErrOrganismExists, name)
+		}
+	}
+	build := &IndexBuild{
+		ID:         uuid.New().String(),
+		Organism:   name,
+		Status:     "running",
+		Stage:      "Queued",
+		Validation: validation,
+		StartedAt:  time.Now(),
+	}
+	m.builds[build.ID] = build
+	m.mu.Unlock()
+
+	go m.runCustomBuild(ctx, build, ref)
+
+	return build.snapshot(), nil
+}
+
+// GetBuild returns the state of an index build.
+func (m *Manager) GetBuild(id string) (*IndexBuild, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	build, ok := m.builds[id]
+	if !ok {
+		return nil, false
+	}
+	return build.snapshot(), true
+}
+
+// pruneBuilds forgets builds that finished more than buildRetention ago. The
+// caller must hold m.mu.
+func (m *Manager) pruneBuilds() {
+	for id, build := range m.builds {
+		if build.CompletedAt != nil && time.Since(*build.CompletedAt) > buildRetention {
+			delete(m.builds, id)
+		}
+	}
+}
+
+func (b *IndexBuild) snapshot() *IndexBuild {
+	copied := *b
+	return &copied
+}
+
+func (m *Manager) updateBuild(build *IndexBuild, progress int, stage string) {
+	m.mu.Lock()
+	build.Progress = progress
+	build.Stage = stage
+	m.mu.Unlock()
+}
+
+func (m *Manager) finishBuild(build *IndexBuild, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	build.CompletedAt = &now
+	if err != nil {
+		build.Status = "failed"
+		build.Error = err.Error()
+		m.logger.Error("custom index build failed", zap.String("organism", build.Organism), zap.Error(err))
+		return
+	}
+	build.Status = "completed"
+	build.Progress = 100
+	build.Stage = "Index ready"
+}
+
+// runCustomBuild builds the Kallisto index of an uploaded transcriptome,
+// installs its annotation and registers the organism in the manifest.
+func (m *Manager) runCustomBuild(ctx context.Context, build *IndexBuild, ref CustomReference) {
+	if ref.UploadDir != "" {
+		defer os.RemoveAll(ref.UploadDir)
+	}
+
+	org := &OrganismInfo{
+		Name:           build.Organism,
+		ScientificName: ref.ScientificName,
+		TaxID:          ref.TaxID,
+		IndexFile:      build.Organism + ".idx",
+		Custom:         true,
+	}
+	if org.ScientificName == "" {
+		org.ScientificName = ref.Name
+	}
+
+	if err := os.MkdirAll(m.referenceDir, 0755); err != nil {
+		m.finishBuild(build, fmt.Errorf("creating reference directory: %w", err))
+		return
+	}
+
+	// Kallisto reads gzipped FASTA, but the annotation table needs a plain file
+	m.updateBuild(build, 10, "Preparing transcriptome")
+	fastaPath := filepath.Join(m.referenceDir, org.Name+"_custom.fa")
+	if err := copyDecompressed(ref.FASTAPath, fastaPath); err != nil {
+		m.finishBuild(build, fmt.Errorf("preparing transcriptome: %w", err))
+		return
+	}
+	defer os.Remove(fastaPath)
+
+	if err := ctx.Err(); err != nil {
+		m.finishBuild(build, err)
+		return
+	}
+
+	m.updateBuild(build, 30, "Building Kallisto index")
+	indexPath := filepath.Join(m.referenceDir, org.IndexFile)
+	if err := m.buildKallistoIndex(ctx, fastaPath, indexPath); err != nil {
+		os.Remove(indexPath)
+		m.finishBuild(build, fmt.Errorf("building index: %w", err))
+		return
+	}
+
+	m.updateBuild(build, 85, "Installing annotation")
+	if ref.GTFPath != "" {
+		if err := copyDecompressed(ref.GTFPath, m.annotationGTFFile(org)); err != nil {
+			m.logger.Warn("failed to install GTF", zap.String("organism", org.Name), zap.Error(err))
+		}
+	}
+	if err := m.writeAnnotationTable(org, fastaPath); err != nil {
+		m.logger.Warn("failed to extract gene annotation", zap.String("organism", org.Name), zap.Error(err))
+	}
+
+	m.updateBuild(build, 95, "Registering organism")
+	m.mu.Lock()
+	org.Available = true
+	m.organisms[org.Name] = org
+	err := m.saveManifest()
+	m.mu.Unlock()
+	if err != nil {
+		m.finishBuild(build, fmt.Errorf("saving manifest: %w", err))
+		return
+	}
+
+	m.logger.Info("custom index built",
+		zap.String("organism", org.Name),
+		zap.String("index", indexPath),
+		zap.Duration("duration", time.Since(build.StartedAt)),
+	)
+	m.finishBuild(build, nil)
+}
+
+// loadManifest registers the custom organisms listed in the manifest.
+func (m *Manager) loadManifest() {
+	data, err := os.ReadFile(filepath.Join(m.referenceDir, manifestFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			m.logger.Warn("failed to read custom organism manifest", zap.Error(err))
+		}
+		return
+	}
+
+	var organisms []*OrganismInfo
+	if err := json.Unmarshal(data, &organisms); err != nil {
+		m.logger.Warn("failed to parse custom organism manifest", zap.Error(err))
+		return
+	}
+	for _, org := range organisms {
+		org.Custom = true
+		org.Available = false
+		m.organisms[NormalizeOrganismName(org.Name)] = org
+	}
+}
+
+// saveManifest writes the custom organisms to the manifest. The caller must
+// hold m.mu.
+func (m *Manager) saveManifest() error {
+	organisms := make([]*OrganismInfo, 0)
+	for _, org := range m.organisms {
+		if org.Custom {
+			organisms = append(organisms, org)
+		}
+	}
+
+	data, err := json.MarshalIndent(organisms, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(m.referenceDir, manifestFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadGTFMaybeGzip loads a plain or gzipped GTF.
+func loadGTFMaybeGzip(path string) (*Annotation, error) {
+	reader, closeFn, err := openMaybeGzip(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+	return parseGTF(reader)
+}
+
+// openMaybeGzip opens a file, transparently decompressing gzip content.
+func openMaybeGzip(path string) (io.Reader, func(), error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buffered := bufio.NewReader(file)
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("opening %s: %w", filepath.Base(path), err)
+		}
+		return gz, func() { gz.Close(); file.Close() }, nil
+	}
+	return buffered, func() { file.Close() }, nil
+}
+
+// copyDecompressed copies a plain or gzipped file to dst as plain text.
+func copyDecompressed(src, dst string) error {
+	reader, closeFn, err := openMaybeGzip(src)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,144 @@
+package reference
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestValidateFASTA(t *testing.T) {
+	tests := []struct {
+		name       string
+		fasta      string
+		gzipped    bool
+		sequences  int
+		annotated  int
+		minLength  int
+		maxLength  int
+		wantErrors []string
+	}{
+		{
+			name:      "valid Ensembl headers",
+			fasta:     ">ENST1.1 cdna gene:ENSG1 gene_symbol:ABC1\nACGT\nAC\n>ENST2.1 cdna gene:ENSG2\nACGTNRY\n",
+			sequences: 2, annotated: 2, minLength: 6, maxLength: 7,
+		},
+		{
+			name:      "gzipped with CRLF line endings",
+			fasta:     ">t1\r\nACGT\r\n>t2\r\nAC\r\n",
+			gzipped:   true,
+			sequences: 2, minLength: 2, maxLength: 4,
+		},
+		{
+			name:      "duplicate ID",
+			fasta:     ">t1\nACGT\n>t1\nACGT\n",
+			sequences: 2, minLength: 4, maxLength: 4,
+			wantErrors: []string{`line 3: duplicate sequence ID "t1" (first seen on line 1)`},
+		},
+		{
+			name:      "empty sequence",
+			fasta:     ">t1\n>t2\nACGT\n",
+			sequences: 2, maxLength: 4,
+			wantErrors: []string{`line 1: sequence "t1" is empty`},
+		},
+		{
+			name:      "invalid nucleotide",
+			fasta:     ">t1\nACGX\n",
+			sequences: 1, minLength: 4, maxLength: 4,
+			wantErrors: []string{`line 2: invalid nucleotide 'X' in sequence "t1"`},
+		},
+		{
+			name:      "missing ID",
+			fasta:     "> description only\nACGT\n",
+			sequences: 1, minLength: 4, maxLength: 4,
+			wantErrors: []string{"line 1: header has no sequence ID"},
+		},
+		{
+			name:       "not FASTA",
+			fasta:      "ACGT\n>t1\nACGT\n",
+			wantErrors: []string{"line 1: sequence data before the first '>' header"},
+		},
+		{
+			name:       "no sequences",
+			fasta:      "\n\n",
+			wantErrors: []string{"FASTA contains no sequences"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transcripts.fa")
+			writeTestFile(t, path, tt.fasta, tt.gzipped)
+
+			validation := &ReferenceValidation{}
+			if _, err := validateFASTA(path, validation); err != nil {
+				t.Fatal(err)
+			}
+
+			if validation.Sequences != tt.sequences || validation.Annotated != tt.annotated {
+				t.Errorf("sequences = %d, annotated = %d, want %d and %d",
+					validation.Sequences, validation.Annotated, tt.sequences, tt.annotated)
+			}
+			if validation.MinLength != tt.minLength || validation.MaxLength != tt.maxLength {
+				t.Errorf("lengths = %d..%d, want %d..%d",
+					validation.MinLength, validation.MaxLength, tt.minLength, tt.maxLength)
+			}
+			if strings.Join(validation.Errors, "\n") != strings.Join(tt.wantErrors, "\n") {
+				t.Errorf("errors = %q, want %q", validation.Errors, tt.wantErrors)
+			}
+			if validation.ErrorCount != len(tt.wantErrors) {
+				t.Errorf("error count = %d, want %d", validation.ErrorCount, len(tt.wantErrors))
+			}
+		})
+	}
+}
+
+func TestEnsureIndexMissingCustomIndex(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(context.Background(), dir, "kallisto", zap.NewNop())
+
+	indexPath := filepath.Join(dir, "custom.idx")
+	writeTestFile(t, indexPath, "index", false)
+	if err := m.AddCustomOrganism("custom", "Custom organism", "", indexPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.EnsureIndex(context.Background(), "custom", nil); err != nil {
+		t.Fatalf("index present: %v", err)
+	}
+
+	os.Remove(indexPath)
+	err := m.EnsureIndex(context.Background(), "custom", nil)
+	if err == nil || !strings.Contains(err.Error(), "upload the reference again") {
+		t.Fatalf("error = %v, want missing index error", err)
+	}
+	if org, _ := m.GetOrganism("custom"); org.Available {
+		t.Error("organism still marked available")
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string, gzipped bool) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if !gzipped {
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	gz := gzip.NewWriter(file)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -23,6 +24,7 @@ type OrganismInfo struct {
 	TranscriptURL  string `json:"transcript_url"`
 	IndexFile      string `json:"index_file"`
 	Available      bool   `json:"available"`
+	Custom         bool   `json:"custom,omitempty"`
 }
 
 // Manager handles reference genome downloads and index management.
@@ -30,16 +32,25 @@ type Manager struct {
 	referenceDir string
 	kallistoPath string
 	organisms    map[string]*OrganismInfo
+	annotations  map[string]*Annotation
+	extractions  map[string]*annotationExtraction // Background annotation extractions by organism
+	builds       map[string]*IndexBuild
 	mu           sync.RWMutex
+	ctx          context.Context // Bounds background annotation extractions
 	logger       *zap.Logger
 }
 
-// NewManager creates a new reference manager.
-func NewManager(referenceDir, kallistoPath string, logger *zap.Logger) *Manager {
+// NewManager creates a new reference manager. Background annotation
+// extractions are aborted when ctx is cancelled.
+func NewManager(ctx context.Context, referenceDir, kallistoPath string, logger *zap.Logger) *Manager {
 	m := &Manager{
 		referenceDir: referenceDir,
 		kallistoPath: kallistoPath,
 		organisms:    make(map[string]*OrganismInfo),
+		annotations:  make(map[string]*Annotation),
+		extractions:  make(map[string]*annotationExtraction),
+		builds:       make(map[string]*IndexBuild),
+		ctx:          ctx,
 		logger:       logger,
 	}
 
@@ -96,6 +107,9 @@ func (m *Manager) registerOrganisms() {
 		IndexFile:      "arabidopsis_thaliana.idx",
 	}
 
+	// Custom organisms added at runtime
+	m.loadManifest()
+
 	// Check which indices are available
 	m.checkAvailability()
 }
@@ -159,6 +173,21 @@ func (m *Manager) GetIndexPath(organism string) (string, error) {
 	return indexPath, nil
 }
 
+// IndexVersion returns the index file of an organism and its version, the
+// modification time of the file, which changes whenever the index is rebuilt.
+func (m *Manager) IndexVersion(organism string) (string, string, error) {
+	indexPath, err := m.GetIndexPath(organism)
+	if err != nil {
+		return "", "", err
+	}
+
+	info, err := os.Stat(indexPath)
+	if err != nil {
+		return "", "", fmt.Errorf("reading index of %s: %w", organism, err)
+	}
+	return filepath.Base(indexPath), info.ModTime().UTC().Format(time.RFC3339), nil
+}
+
 // EnsureIndex ensures a Kallisto index is available, downloading and building if necessary.
 func (m *Manager) EnsureIndex(ctx context.Context, organism string, progressFunc func(stage string, progress int)) error {
 	org, found := m.GetOrganism(organism)
@@ -169,13 +198,24 @@ func (m *Manager) EnsureIndex(ctx context.Context, organism string, progressFunc
 	indexPath := filepath.Join(m.referenceDir, org.IndexFile)
 
 	// Check if already available
-	if org.Available {
+	if org.Available && fileExists(indexPath) {
 		m.logger.Info("index already available", zap.String("organism", organism))
 		if progressFunc != nil {
 			progressFunc("Index already available", 100)
 		}
 		return nil
 	}
+	if org.Available {
+		m.logger.Warn("reference index missing", zap.String("organism", organism), zap.String("index", indexPath))
+		m.mu.Lock()
+		org.Available = false
+		m.mu.Unlock()
+	}
+
+	// Custom organisms have no transcriptome source to rebuild from
+	if org.TranscriptURL == "" {
+		return fmt.Errorf("index of %s is missing and cannot be rebuilt; upload the reference again", org.Name)
+	}
 
 	m.logger.Info("preparing index", zap.String("organism", organism))
 
@@ -213,6 +253,11 @@ func (m *Manager) EnsureIndex(ctx context.Context, organism string, progressFunc
 		return fmt.Errorf("building index: %w", err)
 	}
 
+	// Keep transcript-to-gene annotations for DE results
+	if err := m.writeAnnotationTable(org, unzippedPath); err != nil {
+		m.logger.Warn("failed to extract gene annotation", zap.String("organism", organism), zap.Error(err))
+	}
+
 	// Update availability
 	m.mu.Lock()
 	org.Available = true
@@ -319,7 +364,8 @@ func (m *Manager) AddCustomOrganism(name, scientificName, taxID, indexPath strin
 		TaxID:          taxID,
 		IndexFile:      filepath.Base(destPath),
 		Available:      true,
+		Custom:         true,
 	}
 
-	return nil
+	return m.saveManifest()
 }
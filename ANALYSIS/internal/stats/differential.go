@@ -16,11 +16,21 @@ import (
 	"go.uber.org/zap"
 )
 
+// annotationWait bounds how long a DE run waits for a gene annotation that is
+// still being extracted; the genes are left unnamed when it expires.
+const annotationWait = 10 * time.Second
+
+// GeneAnnotator fills in gene names and descriptions of DE genes.
+type GeneAnnotator interface {
+	AnnotateGenes(ctx context.Context, organism string, genes []models.DEGene) (int, error)
+}
+
 // DifferentialAnalysis provides differential expression analysis.
 type DifferentialAnalysis struct {
 	rExecutor *rbridge.Executor
 	config    config.AnalysisConfig
 	tempDir   string
+	annotator GeneAnnotator
 	logger    *zap.Logger
 }
 
@@ -34,6 +44,11 @@ func NewDifferentialAnalysis(rExec *rbridge.Executor, cfg config.AnalysisConfig,
 	}
 }
 
+// SetAnnotator sets the annotator used to name genes of DE results.
+func (d *DifferentialAnalysis) SetAnnotator(annotator GeneAnnotator) {
+	d.annotator = annotator
+}
+
 // DEOptions holds options for differential expression analysis.
 type DEOptions struct {
 	ExperimentID   uuid.UUID
@@ -46,6 +61,7 @@ type DEOptions struct {
 	PValueThreshold float64
 	Log2FCThreshold float64
 	MinCountFilter  int
+	Organism        string            // Reference organism used to annotate gene names
 }
 
 // Run executes differential expression analysis.
@@ -107,6 +123,8 @@ func (d *DifferentialAnalysis) Run(ctx context.Context, opts DEOptions) (*models
 		return nil, fmt.Errorf("parsing results: %w", err)
 	}
 
+	d.annotate(ctx, deResult, opts.Organism)
+
 	d.logger.Info("differential expression completed",
 		zap.Int("significant_up", deResult.SignificantUp),
 		zap.Int("significant_down", deResult.SignificantDown),
@@ -116,6 +134,32 @@ func (d *DifferentialAnalysis) Run(ctx context.Context, opts DEOptions) (*models
 	return deResult, nil
 }
 
+// annotate populates gene names and descriptions from the reference
+// annotation. Missing annotation never fails the analysis.
+func (d *DifferentialAnalysis) annotate(ctx context.Context, result *models.DifferentialExpressionResult, organism string) {
+	if organism == "" || d.annotator == nil || len(result.Genes) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, annotationWait)
+	defer cancel()
+
+	annotated, err := d.annotator.AnnotateGenes(ctx, organism, result.Genes)
+	if err != nil {
+		d.logger.Warn("gene annotation skipped",
+			zap.String("organism", organism),
+			zap.Error(err),
+		)
+		return
+	}
+
+	d.logger.Info("DE genes annotated",
+		zap.String("organism", organism),
+		zap.Int("annotated", annotated),
+		zap.Int("total", len(result.Genes)),
+	)
+}
+
 // parseResults parses the R output.
 func (d *DifferentialAnalysis) parseResults(result *rbridge.Result, opts DEOptions) (*models.DifferentialExpressionResult, error) {
 	if result.Data == nil {
@@ -126,6 +170,7 @@ func (d *DifferentialAnalysis) parseResults(result *rbridge.Result, opts DEOptio
 		ID:              uuid.New(),
 		ExperimentID:    opts.ExperimentID,
 		Comparison:      opts.Comparison,
+		Organism:        opts.Organism,
 		Method:          opts.Method,
 		PValueThreshold: opts.PValueThreshold,
 		Log2FCThreshold: opts.Log2FCThreshold,
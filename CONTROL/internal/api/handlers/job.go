@@ -3,6 +3,7 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -51,6 +52,19 @@ func (h *JobHandler) Create(c *gin.Context) {
 		return
 	}
 
+	schema, ok := models.JobInputSchemas[req.Type]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported job type: " + string(req.Type)})
+		return
+	}
+	if errs := schema.Validate(req.Input); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "invalid job input",
+			"fields": errs,
+		})
+		return
+	}
+
 	userID, _ := c.Get("user_id")
 
 	// Verify project access
@@ -100,6 +114,22 @@ func (h *JobHandler) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, job)
 }
 
+// Schemas lists the input schema of every job type.
+func (h *JobHandler) Schemas(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"schemas": models.JobInputSchemas})
+}
+
+// Schema returns the input schema of a job type.
+func (h *JobHandler) Schema(c *gin.Context) {
+	schema, ok := models.JobInputSchemas[models.JobType(c.Param("type"))]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown job type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
 // publishJob publishes a job to the appropriate queue.
 func (h *JobHandler) publishJob(c *gin.Context, job *models.Job) error {
 	payload := map[string]any{
@@ -221,6 +251,166 @@ func (h *JobHandler) Cancel(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "job cancelled"})
 }
 
+// BulkJobRequest selects the jobs affected by a bulk operation.
+type BulkJobRequest struct {
+	ProjectID     *uuid.UUID         `json:"project_id"`
+	Status        []models.JobStatus `json:"status"`
+	Type          []models.JobType   `json:"type"`
+	CreatedAfter  *time.Time         `json:"created_after"`
+	CreatedBefore *time.Time         `json:"created_before"`
+
+	// IncludeResults lets bulk delete remove jobs that produced results
+	IncludeResults bool `json:"include_results"`
+}
+
+// bindBulkFilter parses a bulk request and checks project access.
+// It writes the error response itself and returns false on failure.
+func (h *JobHandler) bindBulkFilter(c *gin.Context) (repository.JobFilter, bool) {
+	var req BulkJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return repository.JobFilter{}, false
+	}
+
+	filter := repository.JobFilter{
+		ProjectID:      req.ProjectID,
+		Statuses:       req.Status,
+		Types:          req.Type,
+		CreatedAfter:   req.CreatedAfter,
+		CreatedBefore:  req.CreatedBefore,
+		IncludeResults: req.IncludeResults,
+	}
+
+	if filter.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one filter is required"})
+		return filter, false
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	// Only admins may operate across projects
+	if filter.ProjectID == nil {
+		if role != models.RoleAdmin {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "project_id is required"})
+			return filter, false
+		}
+		return filter, true
+	}
+
+	project, err := h.projectRepo.GetByID(c.Request.Context(), *filter.ProjectID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return filter, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return filter, false
+	}
+
+	if role != models.RoleAdmin && project.OwnerID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// BulkCancel cancels all pending or queued jobs matching a filter.
+func (h *JobHandler) BulkCancel(c *gin.Context) {
+	filter, ok := h.bindBulkFilter(c)
+	if !ok {
+		return
+	}
+
+	ids, err := h.jobRepo.CancelByFilter(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to bulk cancel jobs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	h.logger.Info("jobs cancelled in bulk", zap.Int("count", len(ids)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "jobs cancelled",
+		"affected": len(ids),
+		"job_ids":  ids,
+	})
+}
+
+// BulkRetry re-queues all failed or cancelled jobs matching a filter.
+func (h *JobHandler) BulkRetry(c *gin.Context) {
+	filter, ok := h.bindBulkFilter(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	jobs, err := h.jobRepo.ListByFilter(ctx, filter, []models.JobStatus{models.JobStatusFailed, models.JobStatusCancelled})
+	if err != nil {
+		h.logger.Error("failed to list jobs for retry", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	retried := make([]uuid.UUID, 0, len(jobs))
+	failed := make([]uuid.UUID, 0)
+
+	for _, job := range jobs {
+		if err := h.jobRepo.Reset(ctx, job.ID); err != nil {
+			h.logger.Error("failed to reset job", zap.String("job_id", job.ID.String()), zap.Error(err))
+			failed = append(failed, job.ID)
+			continue
+		}
+
+		if err := h.publishJob(c, job); err != nil {
+			h.logger.Error("failed to publish job", zap.String("job_id", job.ID.String()), zap.Error(err))
+			h.jobRepo.Fail(ctx, job.ID, "failed to queue job")
+			failed = append(failed, job.ID)
+			continue
+		}
+
+		h.jobRepo.UpdateStatus(ctx, job.ID, models.JobStatusQueued)
+		retried = append(retried, job.ID)
+	}
+
+	h.logger.Info("jobs retried in bulk",
+		zap.Int("retried", len(retried)),
+		zap.Int("failed", len(failed)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "jobs retried",
+		"affected": len(retried),
+		"job_ids":  retried,
+		"failed":   failed,
+	})
+}
+
+// BulkDelete deletes all completed, failed or cancelled jobs matching a filter.
+func (h *JobHandler) BulkDelete(c *gin.Context) {
+	filter, ok := h.bindBulkFilter(c)
+	if !ok {
+		return
+	}
+
+	ids, err := h.jobRepo.DeleteByFilter(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to bulk delete jobs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	h.logger.Info("jobs deleted in bulk", zap.Int("count", len(ids)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "jobs deleted",
+		"affected": len(ids),
+		"job_ids":  ids,
+	})
+}
+
 // UpdateProgress updates job progress (internal API).
 func (h *JobHandler) UpdateProgress(c *gin.Context) {
 	idStr := c.Param("id")
@@ -0,0 +1,307 @@
+// Package handlers provides HTTP request handlers.
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guidiju-50/pandora/CONTROL/internal/models"
+	"github.com/guidiju-50/pandora/CONTROL/internal/warehouse/repository"
+	"go.uber.org/zap"
+)
+
+// outlierThreshold is the modified z-score above which a sample metric is
+// flagged as an outlier (Iglewicz & Hoaglin).
+const outlierThreshold = 3.5
+
+// minOutlierSamples is the minimum number of samples reporting a metric
+// before outliers are flagged for it.
+const minOutlierSamples = 3
+
+// qcJobTypes are the job types whose outputs carry per-sample QC metrics.
+var qcJobTypes = []models.JobType{models.JobTypeProcess, models.JobTypeQuantify, models.JobTypeAnalysis}
+
+// qcMetric describes where a QC metric is read from in job outputs.
+type qcMetric struct {
+	name  string
+	paths []string // Dotted paths into the job output
+	field func(*models.SampleQC) **float64
+}
+
+// qcMetrics lists the aggregated metrics. Paths are tried in order, first
+// from the root of the job output and then under "metrics". PROCESSING
+// reports trimming counts under "result" (/jobs/process) or "trimming"
+// (full pipeline) and post-trimming quality under "comparison.after" or
+// "quality_comparison.after". ANALYSIS reports the mapping rate at the root
+// (pipeline jobs) or under "result" (quantify jobs). Rates and percentages
+// are in percent.
+var qcMetrics = []qcMetric{
+	{"raw_reads", []string{"raw_reads", "input_reads", "result.input_reads", "trimming.input_reads"},
+		func(s *models.SampleQC) **float64 { return &s.RawReads }},
+	{"trimmed_reads", []string{"trimmed_reads", "output_reads", "result.output_reads", "trimming.output_reads"},
+		func(s *models.SampleQC) **float64 { return &s.TrimmedReads }},
+	{"survival_rate", []string{"survival_rate", "result.survival_rate", "trimming.survival_rate"},
+		func(s *models.SampleQC) **float64 { return &s.SurvivalRate }},
+	{"q30_percentage", []string{"q30_percentage", "q30", "comparison.after.q30_percentage", "quality_comparison.after.q30_percentage"},
+		func(s *models.SampleQC) **float64 { return &s.Q30 }},
+	{"gc_content", []string{"gc_content", "comparison.after.gc_content", "quality_comparison.after.gc_content"},
+		func(s *models.SampleQC) **float64 { return &s.GCContent }},
+	{"mapping_rate", []string{"mapping_rate", "result.mapping_rate"},
+		func(s *models.SampleQC) **float64 { return &s.MappingRate }},
+}
+
+// QCHandler handles experiment quality control requests.
+type QCHandler struct {
+	sampleRepo  *repository.SampleRepository
+	jobRepo     *repository.JobRepository
+	resultRepo  *repository.ResultRepository
+	projectRepo *repository.ProjectRepository
+	logger      *zap.Logger
+}
+
+// NewQCHandler creates a new QC handler.
+func NewQCHandler(
+	sampleRepo *repository.SampleRepository,
+	jobRepo *repository.JobRepository,
+	resultRepo *repository.ResultRepository,
+	projectRepo *repository.ProjectRepository,
+	logger *zap.Logger,
+) *QCHandler {
+	return &QCHandler{
+		sampleRepo:  sampleRepo,
+		jobRepo:     jobRepo,
+		resultRepo:  resultRepo,
+		projectRepo: projectRepo,
+		logger:      logger,
+	}
+}
+
+// Experiment aggregates the QC metrics of every sample of an experiment and
+// flags outlier samples.
+func (h *QCHandler) Experiment(c *gin.Context) {
+	experimentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid experiment ID"})
+		return
+	}
+
+	projectID, ok := h.authorizeExperiment(c, experimentID)
+	if !ok {
+		return
+	}
+
+	samples, err := h.sampleRepo.ListByExperiment(c.Request.Context(), experimentID)
+	if err != nil {
+		h.logger.Error("failed to list samples", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	jobs, err := h.jobRepo.ListCompleted(c.Request.Context(), projectID, qcJobTypes)
+	if err != nil {
+		h.logger.Error("failed to list completed jobs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	rows := buildSampleQC(samples, jobs)
+	summary := flagOutliers(rows)
+
+	flagged := 0
+	for _, row := range rows {
+		if len(row.Outliers) > 0 {
+			flagged++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"experiment_id":    experimentID,
+		"samples":          rows,
+		"summary":          summary,
+		"total":            len(rows),
+		"outlier_samples":  flagged,
+		"outlier_z_cutoff": outlierThreshold,
+	})
+}
+
+// authorizeExperiment verifies access to an experiment and returns its project ID.
+func (h *QCHandler) authorizeExperiment(c *gin.Context, experimentID uuid.UUID) (uuid.UUID, bool) {
+	projectID, err := h.resultRepo.ExperimentProjectID(c.Request.Context(), experimentID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "experiment not found"})
+			return uuid.Nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return uuid.Nil, false
+	}
+
+	project, err := h.projectRepo.GetByID(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return uuid.Nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	if role != models.RoleAdmin && project.OwnerID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return uuid.Nil, false
+	}
+	return projectID, true
+}
+
+// buildSampleQC collects the metrics reported by jobs for each sample. Jobs
+// are matched by the sample_id or accession in their input; when several jobs
+// report a metric, the most recently completed one wins.
+func buildSampleQC(samples []*models.Sample, jobs []*models.Job) []*models.SampleQC {
+	rows := make([]*models.SampleQC, 0, len(samples))
+	index := make(map[string]*models.SampleQC)
+	for _, sample := range samples {
+		row := &models.SampleQC{
+			SampleID:  sample.ID,
+			Name:      sample.Name,
+			Accession: sample.Accession,
+			Condition: sample.Condition,
+			Replicate: sample.Replicate,
+			Outliers:  []string{},
+		}
+		rows = append(rows, row)
+		index[sample.ID.String()] = row
+		index[sample.Name] = row
+		if sample.Accession != "" {
+			index[sample.Accession] = row
+		}
+	}
+
+	for _, job := range jobs {
+		row := matchSample(index, job.Input)
+		if row == nil {
+			continue
+		}
+		for _, metric := range qcMetrics {
+			if value, ok := outputMetric(job.Output, metric.paths); ok {
+				*metric.field(row) = &value
+			}
+		}
+	}
+
+	for _, row := range rows {
+		if row.SurvivalRate == nil && row.RawReads != nil && row.TrimmedReads != nil && *row.RawReads > 0 {
+			rate := *row.TrimmedReads / *row.RawReads * 100
+			row.SurvivalRate = &rate
+		}
+	}
+
+	return rows
+}
+
+// matchSample finds the sample a job input refers to.
+func matchSample(index map[string]*models.SampleQC, input map[string]any) *models.SampleQC {
+	for _, key := range []string{"sample_id", "accession", "run_accession"} {
+		if value, ok := input[key].(string); ok && value != "" {
+			if row, ok := index[value]; ok {
+				return row
+			}
+		}
+	}
+	return nil
+}
+
+// outputMetric reads the first numeric value found for paths in a job output.
+func outputMetric(output map[string]any, paths []string) (float64, bool) {
+	nested, _ := output["metrics"].(map[string]any)
+	for _, source := range []map[string]any{output, nested} {
+		for _, path := range paths {
+			if value, ok := lookupPath(source, path).(float64); ok {
+				return value, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// lookupPath returns the value at a dotted path of nested JSON objects.
+func lookupPath(source map[string]any, path string) any {
+	var value any = source
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// flagOutliers marks samples whose metrics deviate from the experiment median
+// by more than outlierThreshold modified z-scores and returns per-metric summaries.
+func flagOutliers(rows []*models.SampleQC) map[string]*models.QCMetricSummary {
+	summary := make(map[string]*models.QCMetricSummary)
+
+	for _, metric := range qcMetrics {
+		var values []float64
+		var reporting []*models.SampleQC
+		for _, row := range rows {
+			if value := *metric.field(row); value != nil {
+				values = append(values, *value)
+				reporting = append(reporting, row)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		median := medianOf(sorted)
+		stats := &models.QCMetricSummary{
+			Samples: len(values),
+			Min:     sorted[0],
+			Max:     sorted[len(sorted)-1],
+			Median:  median,
+		}
+		summary[metric.name] = stats
+
+		if len(values) < minOutlierSamples {
+			continue
+		}
+
+		deviations := make([]float64, len(values))
+		for i, value := range values {
+			deviations[i] = math.Abs(value - median)
+		}
+		sort.Float64s(deviations)
+		mad := medianOf(deviations)
+		if mad == 0 {
+			continue
+		}
+
+		for i, value := range values {
+			z := 0.6745 * (value - median) / mad
+			switch {
+			case z > outlierThreshold:
+				reporting[i].Outliers = append(reporting[i].Outliers, metric.name+"_high")
+				stats.Outliers++
+			case z < -outlierThreshold:
+				reporting[i].Outliers = append(reporting[i].Outliers, metric.name+"_low")
+				stats.Outliers++
+			}
+		}
+	}
+
+	return summary
+}
+
+// medianOf returns the median of sorted values.
+func medianOf(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
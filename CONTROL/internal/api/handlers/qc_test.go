@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/guidiju-50/pandora/CONTROL/internal/models"
+)
+
+func TestBuildSampleQCReadsProcessingOutputs(t *testing.T) {
+	sample := &models.Sample{ID: uuid.New(), Name: "s1", Accession: "SRR1"}
+
+	tests := []struct {
+		name     string
+		output   map[string]any
+		raw      float64
+		trimmed  float64
+		survival float64
+		q30      float64
+		gc       float64
+		mapping  float64
+	}{
+		{
+			name: "process job",
+			output: map[string]any{
+				"result":     map[string]any{"input_reads": 1000.0, "output_reads": 900.0, "survival_rate": 90.0},
+				"comparison": map[string]any{"after": map[string]any{"q30_percentage": 93.5, "gc_content": 48.2}},
+			},
+			raw: 1000, trimmed: 900, survival: 90, q30: 93.5, gc: 48.2,
+		},
+		{
+			name: "full pipeline job",
+			output: map[string]any{
+				"trimming":           map[string]any{"input_reads": 2000.0, "output_reads": 1500.0, "survival_rate": 75.0},
+				"quality_comparison": map[string]any{"after": map[string]any{"q30_percentage": 91.0, "gc_content": 50.0}},
+			},
+			raw: 2000, trimmed: 1500, survival: 75, q30: 91, gc: 50,
+		},
+		{
+			name: "quantify job",
+			output: map[string]any{
+				"job_id": "j1",
+				"status": "completed",
+				"result": map[string]any{"sample_id": "s1", "total_reads": 1000.0, "mapping_rate": 87.5},
+			},
+			mapping: 87.5,
+		},
+		{
+			name:    "pipeline job",
+			output:  map[string]any{"total_reads": 1000.0, "mapping_rate": 64.0},
+			mapping: 64,
+		},
+		{
+			name:   "survival rate derived in percent",
+			output: map[string]any{"metrics": map[string]any{"raw_reads": 400.0, "trimmed_reads": 300.0}},
+			raw:    400, trimmed: 300, survival: 75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.Job{Input: map[string]any{"accession": "SRR1"}, Output: tt.output}
+			rows := buildSampleQC([]*models.Sample{sample}, []*models.Job{job})
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+			row := rows[0]
+
+			check := func(name string, got *float64, want float64) {
+				t.Helper()
+				if want == 0 {
+					if got != nil {
+						t.Errorf("%s = %v, want unset", name, *got)
+					}
+					return
+				}
+				if got == nil || *got != want {
+					t.Errorf("%s = %v, want %v", name, got, want)
+				}
+			}
+			check("raw_reads", row.RawReads, tt.raw)
+			check("trimmed_reads", row.TrimmedReads, tt.trimmed)
+			check("survival_rate", row.SurvivalRate, tt.survival)
+			check("q30_percentage", row.Q30, tt.q30)
+			check("gc_content", row.GCContent, tt.gc)
+			check("mapping_rate", row.MappingRate, tt.mapping)
+		})
+	}
+}
+
+func TestFlagOutliers(t *testing.T) {
+	tests := []struct {
+		name         string
+		mapping      []float64
+		wantOutliers []string // Flags expected per sample, "" for none
+		wantCount    int
+	}{
+		{
+			name:         "one low outlier",
+			mapping:      []float64{85, 86, 84, 85.5, 40},
+			wantOutliers: []string{"", "", "", "", "mapping_rate_low"},
+			wantCount:    1,
+		},
+		{
+			name:         "one high outlier",
+			mapping:      []float64{50, 52, 51, 95},
+			wantOutliers: []string{"", "", "", "mapping_rate_high"},
+			wantCount:    1,
+		},
+		{
+			name:         "zero MAD flags nothing",
+			mapping:      []float64{80, 80, 80, 20},
+			wantOutliers: []string{"", "", "", ""},
+		},
+		{
+			name:         "fewer than three samples",
+			mapping:      []float64{90, 10},
+			wantOutliers: []string{"", ""},
+		},
+		{
+			name:         "no outliers",
+			mapping:      []float64{80, 82, 79, 81},
+			wantOutliers: []string{"", "", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := make([]*models.SampleQC, len(tt.mapping))
+			for i := range tt.mapping {
+				rows[i] = &models.SampleQC{MappingRate: &tt.mapping[i], Outliers: []string{}}
+			}
+			// A sample without the metric is ignored
+			rows = append(rows, &models.SampleQC{Outliers: []string{}})
+
+			summary := flagOutliers(rows)
+
+			stats := summary["mapping_rate"]
+			if stats == nil {
+				t.Fatal("no mapping_rate summary")
+			}
+			if stats.Samples != len(tt.mapping) || stats.Outliers != tt.wantCount {
+				t.Errorf("samples/outliers = %d/%d, want %d/%d", stats.Samples, stats.Outliers, len(tt.mapping), tt.wantCount)
+			}
+			for i, want := range tt.wantOutliers {
+				got := ""
+				if len(rows[i].Outliers) > 0 {
+					got = rows[i].Outliers[0]
+				}
+				if got != want || len(rows[i].Outliers) > 1 {
+					t.Errorf("sample %d outliers = %v, want %q", i, rows[i].Outliers, want)
+				}
+			}
+			if _, ok := summary["q30_percentage"]; ok {
+				t.Error("summary for a metric no sample reports")
+			}
+		})
+	}
+}
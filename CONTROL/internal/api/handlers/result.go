@@ -0,0 +1,381 @@
+// Package handlers provides HTTP request handlers.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guidiju-50/pandora/CONTROL/internal/models"
+	"github.com/guidiju-50/pandora/CONTROL/internal/warehouse/repository"
+	"go.uber.org/zap"
+)
+
+// maxDiffGenes bounds the number of gene IDs listed in diff metadata.
+const maxDiffGenes = 100
+
+// parameterKeys are the analysis parameters compared between versions. ANALYSIS
+// sends them explicitly; results without parameters fall back to the keys
+// present in their data.
+var parameterKeys = []string{"method", "pvalue_threshold", "log2fc_threshold", "organism", "index_file", "reference_version"}
+
+// summaryKeys are the result summary fields compared between versions.
+var summaryKeys = []string{"significant_up", "significant_down", "total_tested"}
+
+// ResultHandler handles analysis result requests.
+type ResultHandler struct {
+	resultRepo  *repository.ResultRepository
+	projectRepo *repository.ProjectRepository
+	logger      *zap.Logger
+}
+
+// NewResultHandler creates a new result handler.
+func NewResultHandler(
+	resultRepo *repository.ResultRepository,
+	projectRepo *repository.ProjectRepository,
+	logger *zap.Logger,
+) *ResultHandler {
+	return &ResultHandler{
+		resultRepo:  resultRepo,
+		projectRepo: projectRepo,
+		logger:      logger,
+	}
+}
+
+// CreateResultRequest represents a result submission from an analysis worker.
+type CreateResultRequest struct {
+	ExperimentID uuid.UUID      `json:"experiment_id" binding:"required"`
+	JobID        uuid.UUID      `json:"job_id" binding:"required"`
+	Type         string         `json:"type" binding:"required"`
+	Comparison   string         `json:"comparison"`
+	Parameters   map[string]any `json:"parameters"`
+	Data         map[string]any `json:"data" binding:"required"`
+	FilePath     string         `json:"file_path"`
+}
+
+// Create stores a result as the next version of its experiment/type/comparison series.
+func (h *ResultHandler) Create(c *gin.Context) {
+	var req CreateResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := &models.Result{
+		ExperimentID: req.ExperimentID,
+		JobID:        req.JobID,
+		Type:         req.Type,
+		Comparison:   req.Comparison,
+		Parameters:   req.Parameters,
+		Data:         req.Data,
+		FilePath:     req.FilePath,
+		Version:      1,
+	}
+
+	if result.Comparison == "" {
+		result.Comparison, _ = req.Data["comparison"].(string)
+	}
+	if len(result.Parameters) == 0 {
+		result.Parameters = pickKeys(req.Data, parameterKeys)
+	}
+
+	previous, err := h.resultRepo.Latest(c.Request.Context(), result.ExperimentID, result.Type, result.Comparison)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		h.logger.Error("failed to get latest result version", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if previous != nil {
+		result.Version = previous.Version + 1
+		result.ParentID = &previous.ID
+		result.Diff = diffResults(previous, result)
+	}
+
+	if err := h.resultRepo.Create(c.Request.Context(), result); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a newer version of this result was stored concurrently, retry"})
+			return
+		}
+		h.logger.Error("failed to create result", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	h.logger.Info("result stored",
+		zap.String("result_id", result.ID.String()),
+		zap.String("type", result.Type),
+		zap.String("comparison", result.Comparison),
+		zap.Int("version", result.Version),
+	)
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// List lists the results of an experiment, latest versions only unless all_versions=true.
+func (h *ResultHandler) List(c *gin.Context) {
+	experimentID, err := uuid.Parse(c.Query("experiment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "valid experiment_id is required"})
+		return
+	}
+
+	if !h.authorizeExperiment(c, experimentID) {
+		return
+	}
+
+	latestOnly := c.Query("all_versions") != "true"
+	results, err := h.resultRepo.ListByExperiment(c.Request.Context(), experimentID, latestOnly)
+	if err != nil {
+		h.logger.Error("failed to list results", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"total":   len(results),
+	})
+}
+
+// Get retrieves a result by ID.
+func (h *ResultHandler) Get(c *gin.Context) {
+	result, ok := h.loadResult(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Versions lists every version of the series a result belongs to.
+func (h *ResultHandler) Versions(c *gin.Context) {
+	result, ok := h.loadResult(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	versions, err := h.resultRepo.ListVersions(c.Request.Context(), result.ExperimentID, result.Type, result.Comparison)
+	if err != nil {
+		h.logger.Error("failed to list result versions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"experiment_id": result.ExperimentID,
+		"type":          result.Type,
+		"comparison":    result.Comparison,
+		"versions":      versions,
+		"total":         len(versions),
+	})
+}
+
+// Diff compares a result with another version (the previous one by default).
+func (h *ResultHandler) Diff(c *gin.Context) {
+	result, ok := h.loadResult(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	againstID := c.Query("against")
+	if againstID == "" {
+		if result.ParentID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "result is the first version, specify against"})
+			return
+		}
+		againstID = result.ParentID.String()
+	}
+
+	against, ok := h.loadResult(c, againstID)
+	if !ok {
+		return
+	}
+
+	if against.ExperimentID != result.ExperimentID || against.Type != result.Type {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "results belong to different analyses"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": against.ID,
+		"to":   result.ID,
+		"diff": diffResults(against, result),
+	})
+}
+
+// loadResult fetches a result and verifies access to its experiment.
+func (h *ResultHandler) loadResult(c *gin.Context, idStr string) (*models.Result, bool) {
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid result ID"})
+		return nil, false
+	}
+
+	result, err := h.resultRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "result not found"})
+			return nil, false
+		}
+		h.logger.Error("failed to get result", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return nil, false
+	}
+
+	if !h.authorizeExperiment(c, result.ExperimentID) {
+		return nil, false
+	}
+	return result, true
+}
+
+// authorizeExperiment verifies that the user can access an experiment's project.
+func (h *ResultHandler) authorizeExperiment(c *gin.Context, experimentID uuid.UUID) bool {
+	projectID, err := h.resultRepo.ExperimentProjectID(c.Request.Context(), experimentID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "experiment not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return false
+	}
+
+	project, err := h.projectRepo.GetByID(c.Request.Context(), projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return false
+	}
+
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+	if role != models.RoleAdmin && project.OwnerID != userID.(uuid.UUID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return false
+	}
+	return true
+}
+
+// diffResults builds metadata describing how current differs from previous.
+func diffResults(previous, current *models.Result) map[string]any {
+	diff := map[string]any{
+		"previous_id":      previous.ID,
+		"previous_version": previous.Version,
+	}
+
+	if changes := diffValues(previous.Parameters, current.Parameters); len(changes) > 0 {
+		diff["parameters"] = changes
+	}
+	if changes := diffValues(pickKeys(previous.Data, summaryKeys), pickKeys(current.Data, summaryKeys)); len(changes) > 0 {
+		diff["summary"] = changes
+	}
+	if genes := diffSignificantGenes(previous.Data, current.Data); genes != nil {
+		diff["genes"] = genes
+	}
+
+	return diff
+}
+
+// diffValues returns {"from", "to"} pairs for keys whose values differ.
+func diffValues(previous, current map[string]any) map[string]any {
+	changes := make(map[string]any)
+	for key, value := range current {
+		if old, ok := previous[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = gin.H{"from": previous[key], "to": value}
+		}
+	}
+	for key, old := range previous {
+		if _, ok := current[key]; !ok {
+			changes[key] = gin.H{"from": old, "to": nil}
+		}
+	}
+	return changes
+}
+
+// diffSignificantGenes compares the significant gene sets of two DE results.
+// It returns nil when either result carries no gene list.
+func diffSignificantGenes(previous, current map[string]any) map[string]any {
+	prevGenes, ok := significantGenes(previous)
+	if !ok {
+		return nil
+	}
+	curGenes, ok := significantGenes(current)
+	if !ok {
+		return nil
+	}
+
+	var added, removed, flipped []string
+	for id, direction := range curGenes {
+		old, ok := prevGenes[id]
+		if !ok {
+			added = append(added, id)
+		} else if old != direction {
+			flipped = append(flipped, id)
+		}
+	}
+	for id := range prevGenes {
+		if _, ok := curGenes[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+
+	return map[string]any{
+		"significant_before":    len(prevGenes),
+		"significant_after":     len(curGenes),
+		"added":                 len(added),
+		"removed":               len(removed),
+		"direction_changed":     len(flipped),
+		"added_ids":             truncateIDs(added),
+		"removed_ids":           truncateIDs(removed),
+		"direction_changed_ids": truncateIDs(flipped),
+	}
+}
+
+// significantGenes maps significant gene IDs to their direction.
+func significantGenes(data map[string]any) (map[string]string, bool) {
+	raw, ok := data["genes"].([]any)
+	if !ok {
+		return nil, false
+	}
+
+	genes := make(map[string]string)
+	for _, item := range raw {
+		gene, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if significant, _ := gene["significant"].(bool); !significant {
+			continue
+		}
+		id := fmt.Sprint(gene["gene_id"])
+		direction, _ := gene["direction"].(string)
+		genes[id] = direction
+	}
+	return genes, true
+}
+
+// truncateIDs sorts gene IDs and keeps at most maxDiffGenes of them.
+func truncateIDs(ids []string) []string {
+	sort.Strings(ids)
+	if len(ids) > maxDiffGenes {
+		ids = ids[:maxDiffGenes]
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return ids
+}
+
+// pickKeys copies the given keys from m when present.
+func pickKeys(m map[string]any, keys []string) map[string]any {
+	picked := make(map[string]any)
+	for _, key := range keys {
+		if value, ok := m[key]; ok {
+			picked[key] = value
+		}
+	}
+	return picked
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guidiju-50/pandora/CONTROL/internal/models"
+)
+
+// deGenes builds the gene list of a DE result as decoded from JSON.
+func deGenes(genes ...string) []any {
+	list := make([]any, 0, len(genes))
+	for _, g := range genes {
+		var id, direction string
+		fmt.Sscanf(g, "%s %s", &id, &direction)
+		list = append(list, map[string]any{
+			"gene_id":     id,
+			"significant": direction != "none",
+			"direction":   direction,
+		})
+	}
+	return list
+}
+
+func TestDiffSignificantGenes(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous map[string]any
+		current  map[string]any
+		want     map[string]any
+	}{
+		{
+			name:     "no gene list",
+			previous: map[string]any{"significant_up": 1.0},
+			current:  map[string]any{"genes": deGenes("g1 up")},
+		},
+		{
+			name:     "added, removed and flipped genes",
+			previous: map[string]any{"genes": deGenes("g1 up", "g2 down", "g3 up", "g4 none")},
+			current:  map[string]any{"genes": deGenes("g1 up", "g2 up", "g4 down", "g5 none")},
+			want: map[string]any{
+				"significant_before":    3,
+				"significant_after":     3,
+				"added":                 1,
+				"removed":               1,
+				"direction_changed":     1,
+				"added_ids":             []string{"g4"},
+				"removed_ids":           []string{"g3"},
+				"direction_changed_ids": []string{"g2"},
+			},
+		},
+		{
+			name:     "unchanged",
+			previous: map[string]any{"genes": deGenes("g1 up")},
+			current:  map[string]any{"genes": deGenes("g1 up")},
+			want: map[string]any{
+				"significant_before":    1,
+				"significant_after":     1,
+				"added":                 0,
+				"removed":               0,
+				"direction_changed":     0,
+				"added_ids":             []string{},
+				"removed_ids":           []string{},
+				"direction_changed_ids": []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffSignificantGenes(tt.previous, tt.current)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("got %v, want nil", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffSignificantGenesTruncatesIDs(t *testing.T) {
+	genes := make([]string, 0, maxDiffGenes+10)
+	for i := 0; i < maxDiffGenes+10; i++ {
+		genes = append(genes, fmt.Sprintf("g%03d up", i))
+	}
+	got := diffSignificantGenes(map[string]any{"genes": deGenes()}, map[string]any{"genes": deGenes(genes...)})
+
+	if got["added"] != maxDiffGenes+10 {
+		t.Errorf("added = %v, want %d", got["added"], maxDiffGenes+10)
+	}
+	ids := got["added_ids"].([]string)
+	if len(ids) != maxDiffGenes || ids[0] != "g000" {
+		t.Errorf("added_ids has %d IDs starting at %s, want %d sorted IDs", len(ids), ids[0], maxDiffGenes)
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	previous := &models.Result{
+		ID:      uuid.New(),
+		Version: 1,
+		Parameters: map[string]any{
+			"method":            "deseq2",
+			"organism":          "homo_sapiens",
+			"index_file":        "homo_sapiens.idx",
+			"reference_version": "2026-01-10T08:00:00Z",
+		},
+		Data: map[string]any{"significant_up": 10.0, "significant_down": 5.0, "total_tested": 100.0, "genes": deGenes("g1 up")},
+	}
+
+	tests := []struct {
+		name       string
+		parameters map[string]any
+		data       map[string]any
+		wantKeys   []string
+		wantParams map[string]any
+	}{
+		{
+			name:       "identical rerun",
+			parameters: previous.Parameters,
+			data:       map[string]any{"significant_up": 10.0, "significant_down": 5.0, "total_tested": 100.0, "genes": deGenes("g1 up")},
+			wantKeys:   []string{"previous_id", "previous_version", "genes"},
+		},
+		{
+			name: "rebuilt reference",
+			parameters: map[string]any{
+				"method":            "deseq2",
+				"organism":          "homo_sapiens",
+				"index_file":        "homo_sapiens.idx",
+				"reference_version": "2026-03-02T12:30:00Z",
+			},
+			data:     map[string]any{"significant_up": 12.0, "significant_down": 5.0, "total_tested": 100.0, "genes": deGenes("g1 up", "g2 up")},
+			wantKeys: []string{"previous_id", "previous_version", "parameters", "summary", "genes"},
+			wantParams: map[string]any{
+				"reference_version": gin.H{"from": "2026-01-10T08:00:00Z", "to": "2026-03-02T12:30:00Z"},
+			},
+		},
+		{
+			name:       "parameter removed",
+			parameters: map[string]any{"method": "deseq2", "organism": "homo_sapiens", "index_file": "homo_sapiens.idx"},
+			data:       map[string]any{"significant_up": 10.0, "significant_down": 5.0, "total_tested": 100.0},
+			wantKeys:   []string{"previous_id", "previous_version", "parameters"},
+			wantParams: map[string]any{
+				"reference_version": gin.H{"from": "2026-01-10T08:00:00Z", "to": nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := &models.Result{ID: uuid.New(), Version: 2, Parameters: tt.parameters, Data: tt.data}
+			diff := diffResults(previous, current)
+
+			if len(diff) != len(tt.wantKeys) {
+				t.Errorf("diff keys = %v, want %v", diff, tt.wantKeys)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := diff[key]; !ok {
+					t.Errorf("diff misses %q", key)
+				}
+			}
+			if diff["previous_id"] != previous.ID || diff["previous_version"] != 1 {
+				t.Errorf("previous = %v/%v", diff["previous_id"], diff["previous_version"])
+			}
+			if tt.wantParams != nil && !reflect.DeepEqual(diff["parameters"], tt.wantParams) {
+				t.Errorf("parameters = %v, want %v", diff["parameters"], tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestPickKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]any
+		keys []string
+		want map[string]any
+	}{
+		{"subset", map[string]any{"method": "deseq2", "organism": "mus_musculus", "genes": []any{}}, parameterKeys,
+			map[string]any{"method": "deseq2", "organism": "mus_musculus"}},
+		{"nil value kept", map[string]any{"method": nil}, []string{"method"}, map[string]any{"method": nil}},
+		{"missing keys", map[string]any{"other": 1}, summaryKeys, map[string]any{}},
+		{"nil map", nil, summaryKeys, map[string]any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickKeys(tt.m, tt.keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pickKeys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
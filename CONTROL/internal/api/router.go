@@ -35,12 +35,16 @@ func SetupRouter(
 	userRepo := repository.NewUserRepository(db)
 	projectRepo := repository.NewProjectRepository(db)
 	jobRepo := repository.NewJobRepository(db)
+	resultRepo := repository.NewResultRepository(db)
+	sampleRepo := repository.NewSampleRepository(db)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userRepo, jwtManager, logger)
 	projectHandler := handlers.NewProjectHandler(projectRepo, logger)
 	jobHandler := handlers.NewJobHandler(jobRepo, projectRepo, rabbitmq, logger)
 	warehouseHandler := handlers.NewWarehouseHandler(db, logger)
+	resultHandler := handlers.NewResultHandler(resultRepo, projectRepo, logger)
+	qcHandler := handlers.NewQCHandler(sampleRepo, jobRepo, resultRepo, projectRepo, logger)
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -86,8 +90,30 @@ func SetupRouter(
 			{
 				jobs.POST("", jobHandler.Create)
 				jobs.GET("", jobHandler.List)
+				jobs.GET("/schemas", jobHandler.Schemas)
+				jobs.GET("/schemas/:type", jobHandler.Schema)
 				jobs.GET("/:id", jobHandler.Get)
 				jobs.POST("/:id/cancel", jobHandler.Cancel)
+
+				// Bulk operations by filter
+				jobs.POST("/bulk/cancel", jobHandler.BulkCancel)
+				jobs.POST("/bulk/retry", jobHandler.BulkRetry)
+				jobs.POST("/bulk/delete", jobHandler.BulkDelete)
+			}
+
+			// Experiments
+			experiments := protected.Group("/experiments")
+			{
+				experiments.GET("/:id/qc", qcHandler.Experiment)
+			}
+
+			// Analysis results (versioned)
+			results := protected.Group("/results")
+			{
+				results.GET("", resultHandler.List)
+				results.GET("/:id", resultHandler.Get)
+				results.GET("/:id/versions", resultHandler.Versions)
+				results.GET("/:id/diff", resultHandler.Diff)
 			}
 
 			// Warehouse (search)
@@ -115,6 +141,9 @@ func SetupRouter(
 
 			// Warehouse imports
 			internal.POST("/warehouse/records", warehouseHandler.ImportRecords)
+
+			// Analysis results from workers
+			internal.POST("/results", resultHandler.Create)
 		}
 	}
 
@@ -136,12 +136,18 @@ type SRARecord struct {
 	ImportedAt      time.Time `json:"imported_at" db:"imported_at"`
 }
 
-// Result represents an analysis result.
+// Result represents an analysis result. Re-runs of the same analysis on an
+// experiment/comparison are stored as successive versions linked by ParentID.
 type Result struct {
 	ID           uuid.UUID      `json:"id" db:"id"`
 	ExperimentID uuid.UUID      `json:"experiment_id" db:"experiment_id"`
 	JobID        uuid.UUID      `json:"job_id" db:"job_id"`
 	Type         string         `json:"type" db:"type"`
+	Comparison   string         `json:"comparison,omitempty" db:"comparison"`
+	Version      int            `json:"version" db:"version"`
+	ParentID     *uuid.UUID     `json:"parent_id,omitempty" db:"parent_id"`
+	Parameters   map[string]any `json:"parameters" db:"parameters"`
+	Diff         map[string]any `json:"diff,omitempty" db:"diff"`
 	Data         map[string]any `json:"data" db:"data"`
 	FilePath     string         `json:"file_path,omitempty" db:"file_path"`
 	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
@@ -155,3 +161,29 @@ type RefreshToken struct {
 	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
+
+// SampleQC aggregates the quality control metrics of a sample across its
+// processing and quantification jobs. Metrics not yet reported are nil.
+type SampleQC struct {
+	SampleID     uuid.UUID `json:"sample_id"`
+	Name         string    `json:"name"`
+	Accession    string    `json:"accession,omitempty"`
+	Condition    string    `json:"condition,omitempty"`
+	Replicate    int       `json:"replicate"`
+	RawReads     *float64  `json:"raw_reads,omitempty"`
+	TrimmedReads *float64  `json:"trimmed_reads,omitempty"`
+	SurvivalRate *float64  `json:"survival_rate,omitempty"`
+	Q30          *float64  `json:"q30_percentage,omitempty"`
+	GCContent    *float64  `json:"gc_content,omitempty"`
+	MappingRate  *float64  `json:"mapping_rate,omitempty"`
+	Outliers     []string  `json:"outliers"`
+}
+
+// QCMetricSummary summarizes one QC metric across the samples of an experiment.
+type QCMetricSummary struct {
+	Samples  int     `json:"samples"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Median   float64 `json:"median"`
+	Outliers int     `json:"outliers"`
+}
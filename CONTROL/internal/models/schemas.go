@@ -0,0 +1,134 @@
+package models
+
+import "github.com/guidiju-50/pandora/CONTROL/pkg/validator"
+
+// accessionPattern matches SRA/ENA/DDBJ study, experiment, run and sample accessions.
+const accessionPattern = `^(SRP|SRX|SRR|SRS|ERP|ERX|ERR|ERS|DRP|DRX|DRR|DRS)[0-9]+$`
+
+// JobInputSchemas maps each job type to the JSON schema of its input.
+var JobInputSchemas = map[JobType]*validator.Schema{
+	JobTypeScrape: inputSchema("Scrape job input", "Search SRA/ENA and import matching records.",
+		map[string]*validator.Schema{
+			"query":       str("Search query, e.g. organism and strategy"),
+			"accession":   accession("Single accession to import"),
+			"accessions":  {Type: "array", Description: "Accessions to import", Items: accession(""), MinItems: intPtr(1)},
+			"database":    enum("Source database", "sra", "ena"),
+			"max_results": integer("Maximum number of records", 1, 10000),
+		},
+		nil,
+		[]string{"query"}, []string{"accession"}, []string{"accessions"},
+	),
+	JobTypeProcess: inputSchema("Process job input", "Download and quality-trim reads.",
+		map[string]*validator.Schema{
+			"sample_id":      str("Sample the reads belong to"),
+			"accession":      accession("Run accession to download"),
+			"input_file_1":   str("Local FASTQ (read 1)"),
+			"input_file_2":   str("Local FASTQ (read 2, paired-end)"),
+			"output_dir":     str("Output directory"),
+			"use_prefetch":   boolean("Download with prefetch before fasterq-dump"),
+			"trimmer":        withDefault(enum("Read trimmer", "trimmomatic", "fastp"), "trimmomatic"),
+			"stream":         boolean("Stream ENA reads into the trimmer without storing raw FASTQ"),
+			"leading":        integer("Trimmomatic LEADING quality", 0, 60),
+			"trailing":       integer("Trimmomatic TRAILING quality", 0, 60),
+			"sliding_window": {Type: "string", Description: "Trimmomatic SLIDINGWINDOW as size:quality", Pattern: `^[0-9]+:[0-9]+$`},
+			"min_len":        integer("Trimmomatic MINLEN", 1, 1000),
+			"crop_length":    integer("Crop trimmed reads to this length (0 disables)", 0, 1000),
+			"keep_shorter":   boolean("Keep reads shorter than crop_length"),
+		},
+		nil,
+		[]string{"accession"}, []string{"input_file_1"},
+	),
+	JobTypeQuantify: inputSchema("Quantify job input", "Quantify transcript abundance.",
+		map[string]*validator.Schema{
+			"tool":       withDefault(enum("Quantification tool", "kallisto", "rsem"), "kallisto"),
+			"sample_id":  str("Sample the reads belong to"),
+			"reads1":     str("FASTQ (read 1)"),
+			"reads2":     str("FASTQ (read 2, paired-end)"),
+			"index":      str("Kallisto index"),
+			"reference":  str("RSEM reference prefix"),
+			"output_dir": str("Output directory"),
+			"bootstrap":  integer("Kallisto bootstrap samples", 0, 1000),
+		},
+		[]string{"sample_id", "reads1"},
+		[]string{"index"}, []string{"reference"},
+	),
+	JobTypeAnalysis: inputSchema("Analysis job input", "Differential expression between two conditions.",
+		map[string]*validator.Schema{
+			"experiment_id":    str("Experiment the analysis belongs to"),
+			"counts_file":      str("Counts matrix"),
+			"metadata_file":    str("Sample metadata"),
+			"comparison":       str("Comparison name, e.g. treatment_vs_control"),
+			"condition1":       str("Reference condition"),
+			"condition2":       str("Test condition"),
+			"method":           withDefault(enum("DE method", "deseq2", "edger", "limma"), "deseq2"),
+			"pvalue_threshold": withDefault(number("Adjusted p-value cutoff", 0, 1), 0.05),
+			"log2fc_threshold": withDefault(number("Absolute log2 fold change cutoff", 0, 100), 1.0),
+			"organism":         str("Reference organism used to annotate gene names"),
+		},
+		[]string{"counts_file", "metadata_file", "condition1", "condition2"},
+	),
+	JobTypeEnrichment: inputSchema("Enrichment job input", "GO/KEGG enrichment of a gene list.",
+		map[string]*validator.Schema{
+			"experiment_id":    str("Experiment the analysis belongs to"),
+			"genes":            {Type: "array", Description: "Gene IDs to test", Items: str(""), MinItems: intPtr(1)},
+			"result_id":        str("DE result whose significant genes are tested"),
+			"organism":         str("Organism, e.g. Homo sapiens"),
+			"database":         withDefault(enum("Annotation database", "go", "kegg"), "go"),
+			"pvalue_threshold": withDefault(number("Adjusted p-value cutoff", 0, 1), 0.05),
+		},
+		[]string{"organism"},
+		[]string{"genes"}, []string{"result_id"},
+	),
+}
+
+// inputSchema builds a closed object schema with its patterns compiled. Each
+// anyOf entry lists fields that together satisfy the input's source requirement.
+func inputSchema(title, description string, props map[string]*validator.Schema, required []string, anyOf ...[]string) *validator.Schema {
+	closed := false
+	schema := &validator.Schema{
+		Schema:               "http://json-schema.org/draft-07/schema#",
+		Title:                title,
+		Description:          description,
+		Type:                 "object",
+		Properties:           props,
+		Required:             required,
+		AdditionalProperties: &closed,
+	}
+	for _, fields := range anyOf {
+		schema.AnyOf = append(schema.AnyOf, &validator.Schema{Required: fields})
+	}
+	return validator.MustCompile(schema)
+}
+
+func str(description string) *validator.Schema {
+	return &validator.Schema{Type: "string", Description: description, MinLength: intPtr(1)}
+}
+
+func accession(description string) *validator.Schema {
+	return &validator.Schema{Type: "string", Description: description, Pattern: accessionPattern}
+}
+
+func boolean(description string) *validator.Schema {
+	return &validator.Schema{Type: "boolean", Description: description}
+}
+
+func integer(description string, minimum, maximum float64) *validator.Schema {
+	return &validator.Schema{Type: "integer", Description: description, Minimum: &minimum, Maximum: &maximum}
+}
+
+func number(description string, minimum, maximum float64) *validator.Schema {
+	return &validator.Schema{Type: "number", Description: description, Minimum: &minimum, Maximum: &maximum}
+}
+
+func enum(description string, values ...any) *validator.Schema {
+	return &validator.Schema{Type: "string", Description: description, Enum: values}
+}
+
+func withDefault(schema *validator.Schema, value any) *validator.Schema {
+	schema.Default = value
+	return schema
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJobInputSchemas(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobType JobType
+		input   string
+		fields  []string // Fields with errors, in order
+		message string   // Substring of the first error message
+	}{
+		// scrape: one of query, accession or accessions
+		{name: "scrape query", jobType: JobTypeScrape, input: `{"query": "Homo sapiens RNA-Seq", "database": "ena", "max_results": 50}`},
+		{name: "scrape accession", jobType: JobTypeScrape, input: `{"accession": "SRR1234567"}`},
+		{name: "scrape accessions", jobType: JobTypeScrape, input: `{"accessions": ["SRR1", "ERR2", "DRR3"]}`},
+		{name: "scrape without source", jobType: JobTypeScrape, input: `{"database": "sra"}`, fields: []string{"input"}, message: "requires one of: query, accession, accessions"},
+		{name: "scrape null source", jobType: JobTypeScrape, input: `{"query": null}`, fields: []string{"input"}},
+		{name: "scrape misspelled accession", jobType: JobTypeScrape, input: `{"acession": "SRR1"}`, fields: []string{"acession", "input"}, message: `unknown field (did you mean "accession"?)`},
+		{name: "scrape unknown field", jobType: JobTypeScrape, input: `{"query": "x", "organism_name": "y"}`, fields: []string{"organism_name"}, message: "unknown field"},
+		{name: "scrape bad accession", jobType: JobTypeScrape, input: `{"accessions": ["SRR1", "GSM2"]}`, fields: []string{"accessions[1]"}, message: "must match pattern"},
+		{name: "scrape empty accessions", jobType: JobTypeScrape, input: `{"accessions": []}`, fields: []string{"accessions"}, message: "at least 1 items"},
+		{name: "scrape bad database", jobType: JobTypeScrape, input: `{"query": "x", "database": "geo"}`, fields: []string{"database"}, message: "must be one of"},
+		{name: "scrape fractional max_results", jobType: JobTypeScrape, input: `{"query": "x", "max_results": 2.5}`, fields: []string{"max_results"}, message: "must be an integer"},
+
+		// process: accession or input_file_1
+		{name: "process accession", jobType: JobTypeProcess, input: `{"sample_id": "s1", "accession": "SRR1", "trimmer": "fastp", "stream": true, "sliding_window": "4:15", "min_len": 36}`},
+		{name: "process local files", jobType: JobTypeProcess, input: `{"input_file_1": "/data/r1.fq", "input_file_2": "/data/r2.fq", "crop_length": 50, "keep_shorter": false}`},
+		{name: "process without reads", jobType: JobTypeProcess, input: `{"sample_id": "s1"}`, fields: []string{"input"}, message: "requires one of: accession, input_file_1"},
+		{name: "process bad window", jobType: JobTypeProcess, input: `{"accession": "SRR1", "sliding_window": "4-15"}`, fields: []string{"sliding_window"}},
+		{name: "process out of range", jobType: JobTypeProcess, input: `{"accession": "SRR1", "leading": 61, "min_len": 0}`, fields: []string{"leading", "min_len"}, message: "must be <= 60"},
+		{name: "process unknown trimmer", jobType: JobTypeProcess, input: `{"accession": "SRR1", "trimmer": "cutadapt"}`, fields: []string{"trimmer"}},
+		{name: "process string boolean", jobType: JobTypeProcess, input: `{"accession": "SRR1", "stream": "yes"}`, fields: []string{"stream"}, message: "must be a boolean"},
+
+		// quantify: sample_id and reads1 required, index or reference
+		{name: "quantify kallisto", jobType: JobTypeQuantify, input: `{"sample_id": "s1", "reads1": "r1.fq", "index": "idx", "bootstrap": 100}`},
+		{name: "quantify rsem", jobType: JobTypeQuantify, input: `{"tool": "rsem", "sample_id": "s1", "reads1": "r1.fq", "reference": "ref"}`},
+		{name: "quantify missing required", jobType: JobTypeQuantify, input: `{"index": "idx"}`, fields: []string{"reads1", "sample_id"}, message: "is required"},
+		{name: "quantify without index", jobType: JobTypeQuantify, input: `{"sample_id": "s1", "reads1": "r1.fq"}`, fields: []string{"input"}, message: "requires one of: index, reference"},
+		{name: "quantify empty sample", jobType: JobTypeQuantify, input: `{"sample_id": "", "reads1": "r1.fq", "index": "idx"}`, fields: []string{"sample_id"}, message: "at least 1 characters"},
+		{name: "quantify bad tool", jobType: JobTypeQuantify, input: `{"tool": "salmon", "sample_id": "s1", "reads1": "r1.fq", "index": "idx"}`, fields: []string{"tool"}},
+
+		// analysis: no anyOf, four required fields
+		{name: "analysis", jobType: JobTypeAnalysis, input: `{"counts_file": "c.tsv", "metadata_file": "m.tsv", "condition1": "control", "condition2": "treated", "method": "edger", "pvalue_threshold": 0.01, "log2fc_threshold": 2}`},
+		{name: "analysis missing conditions", jobType: JobTypeAnalysis, input: `{"counts_file": "c.tsv", "metadata_file": "m.tsv"}`, fields: []string{"condition1", "condition2"}, message: "is required"},
+		{name: "analysis bad threshold", jobType: JobTypeAnalysis, input: `{"counts_file": "c.tsv", "metadata_file": "m.tsv", "condition1": "a", "condition2": "b", "pvalue_threshold": 1.5}`, fields: []string{"pvalue_threshold"}, message: "must be <= 1"},
+		{name: "analysis misspelled method", jobType: JobTypeAnalysis, input: `{"counts_file": "c.tsv", "metadata_file": "m.tsv", "condition1": "a", "condition2": "b", "metod": "limma"}`, fields: []string{"metod"}, message: `did you mean "method"?`},
+
+		// enrichment: organism required, genes or result_id
+		{name: "enrichment genes", jobType: JobTypeEnrichment, input: `{"organism": "Homo sapiens", "genes": ["TP53", "BRCA1"], "database": "kegg"}`},
+		{name: "enrichment result", jobType: JobTypeEnrichment, input: `{"organism": "Homo sapiens", "result_id": "r1"}`},
+		{name: "enrichment without genes", jobType: JobTypeEnrichment, input: `{"organism": "Homo sapiens"}`, fields: []string{"input"}, message: "requires one of: genes, result_id"},
+		{name: "enrichment non-string gene", jobType: JobTypeEnrichment, input: `{"organism": "Homo sapiens", "genes": ["TP53", 7]}`, fields: []string{"genes[1]"}, message: "must be a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, ok := JobInputSchemas[tt.jobType]
+			if !ok {
+				t.Fatalf("no schema for job type %q", tt.jobType)
+			}
+			var input map[string]any
+			if err := json.Unmarshal([]byte(tt.input), &input); err != nil {
+				t.Fatal(err)
+			}
+
+			errs := schema.Validate(input)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("got errors %v, want fields %v", errs, tt.fields)
+			}
+			for i, field := range tt.fields {
+				if errs[i].Field != field {
+					t.Errorf("error %d on %q, want %q", i, errs[i].Field, field)
+				}
+			}
+			if tt.message != "" && !strings.Contains(errs[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", errs[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestJobInputSchemasUnknownFieldSuggestion(t *testing.T) {
+	tests := []struct {
+		jobType JobType
+		field   string
+		want    string
+	}{
+		{JobTypeScrape, "acession", `unknown field (did you mean "accession"?)`},
+		{JobTypeScrape, "Accesions", `unknown field (did you mean "accessions"?)`},
+		{JobTypeProcess, "sampleid", `unknown field (did you mean "sample_id"?)`},
+		{JobTypeQuantify, "read1", `unknown field (did you mean "reads1"?)`},
+		{JobTypeEnrichment, "zzzzzzzz", "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.jobType)+"/"+tt.field, func(t *testing.T) {
+			var message string
+			for _, err := range JobInputSchemas[tt.jobType].Validate(map[string]any{tt.field: "x"}) {
+				if err.Field == tt.field {
+					message = err.Message
+				}
+			}
+			if message != tt.want {
+				t.Errorf("message = %q, want %q", message, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobInputSchemasCoverJobTypes(t *testing.T) {
+	for _, jobType := range []JobType{JobTypeScrape, JobTypeProcess, JobTypeQuantify, JobTypeAnalysis, JobTypeEnrichment} {
+		schema, ok := JobInputSchemas[jobType]
+		if !ok {
+			t.Errorf("no schema for job type %q", jobType)
+			continue
+		}
+		if schema.AdditionalProperties == nil || *schema.AdditionalProperties {
+			t.Errorf("schema for %q accepts unknown fields", jobType)
+		}
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/guidiju-50/pandora/CONTROL/internal/models"
 )
 
@@ -98,6 +101,35 @@ func (r *JobRepository) ListByStatus(ctx context.Context, status models.JobStatu
 	return jobs, nil
 }
 
+// ListCompleted retrieves the completed jobs of the given types in a project,
+// oldest completion first.
+func (r *JobRepository) ListCompleted(ctx context.Context, projectID uuid.UUID, types []models.JobType) ([]*models.Job, error) {
+	typeNames := make([]string, len(types))
+	for i, t := range types {
+		typeNames[i] = string(t)
+	}
+
+	var rows []jobRow
+	query := `
+		SELECT * FROM jobs
+		WHERE project_id = $1 AND status = $2 AND type = ANY($3)
+		ORDER BY completed_at ASC`
+	err := r.db.SelectContext(ctx, &rows, query, projectID, models.JobStatusCompleted, pq.Array(typeNames))
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*models.Job, 0, len(rows))
+	for _, row := range rows {
+		job, err := row.toModel()
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
 // UpdateStatus updates the status of a job.
 func (r *JobRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status models.JobStatus) error {
 	query := `UPDATE jobs SET status = $1 WHERE id = $2`
@@ -138,6 +170,144 @@ func (r *JobRepository) Fail(ctx context.Context, id uuid.UUID, errMsg string) e
 	return err
 }
 
+// Reset clears the outcome of a job and returns it to pending so it can be re-queued.
+func (r *JobRepository) Reset(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE jobs SET status = $1, progress = 0, output = '{}', error = '', started_at = NULL, completed_at = NULL
+		WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, models.JobStatusPending, id)
+	return err
+}
+
+// JobFilter selects the jobs affected by a bulk operation.
+type JobFilter struct {
+	ProjectID     *uuid.UUID
+	Statuses      []models.JobStatus
+	Types         []models.JobType
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// IncludeResults allows DeleteByFilter to remove jobs that produced results,
+	// which are deleted with them. It is not a selection criterion.
+	IncludeResults bool
+}
+
+// IsEmpty reports whether the filter has no criteria and would match every job.
+func (f JobFilter) IsEmpty() bool {
+	return f.ProjectID == nil && len(f.Statuses) == 0 && len(f.Types) == 0 &&
+		f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+// whereClause builds the SQL conditions for the filter. Only jobs in one of the
+// allowed statuses are matched; ok is false when the filter excludes all of them.
+func (f JobFilter) whereClause(allowed []models.JobStatus) (clause string, args []any, ok bool) {
+	statuses := make([]string, 0, len(allowed))
+	for _, status := range allowed {
+		if len(f.Statuses) == 0 || containsStatus(f.Statuses, status) {
+			statuses = append(statuses, string(status))
+		}
+	}
+	if len(statuses) == 0 {
+		return "", nil, false
+	}
+
+	conditions := []string{"status = ANY($1)"}
+	args = []any{pq.Array(statuses)}
+
+	if f.ProjectID != nil {
+		args = append(args, *f.ProjectID)
+		conditions = append(conditions, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+	if len(f.Types) > 0 {
+		types := make([]string, 0, len(f.Types))
+		for _, t := range f.Types {
+			types = append(types, string(t))
+		}
+		args = append(args, pq.Array(types))
+		conditions = append(conditions, fmt.Sprintf("type = ANY($%d)", len(args)))
+	}
+	if f.CreatedAfter != nil {
+		args = append(args, *f.CreatedAfter)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if f.CreatedBefore != nil {
+		args = append(args, *f.CreatedBefore)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args, true
+}
+
+func containsStatus(statuses []models.JobStatus, status models.JobStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ListByFilter retrieves the jobs matching a filter whose status is one of allowed.
+func (r *JobRepository) ListByFilter(ctx context.Context, filter JobFilter, allowed []models.JobStatus) ([]*models.Job, error) {
+	where, args, ok := filter.whereClause(allowed)
+	if !ok {
+		return []*models.Job{}, nil
+	}
+
+	var rows []jobRow
+	query := `SELECT * FROM jobs WHERE ` + where + ` ORDER BY priority DESC, created_at ASC`
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*models.Job, 0, len(rows))
+	for _, row := range rows {
+		job, err := row.toModel()
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// CancelByFilter cancels all pending or queued jobs matching a filter and
+// returns the IDs of the cancelled jobs.
+func (r *JobRepository) CancelByFilter(ctx context.Context, filter JobFilter) ([]uuid.UUID, error) {
+	where, args, ok := filter.whereClause([]models.JobStatus{models.JobStatusPending, models.JobStatusQueued})
+	if !ok {
+		return []uuid.UUID{}, nil
+	}
+
+	args = append(args, models.JobStatusCancelled, time.Now())
+	query := fmt.Sprintf(`UPDATE jobs SET status = $%d, completed_at = $%d WHERE %s RETURNING id`,
+		len(args)-1, len(args), where)
+
+	ids := []uuid.UUID{}
+	err := r.db.SelectContext(ctx, &ids, query, args...)
+	return ids, err
+}
+
+// DeleteByFilter deletes all finished jobs matching a filter and returns the
+// IDs of the deleted jobs. Pending, queued and running jobs are never deleted.
+// Results cascade with their job, so jobs that produced results are skipped
+// unless the filter sets IncludeResults.
+func (r *JobRepository) DeleteByFilter(ctx context.Context, filter JobFilter) ([]uuid.UUID, error) {
+	where, args, ok := filter.whereClause([]models.JobStatus{
+		models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled,
+	})
+	if !ok {
+		return []uuid.UUID{}, nil
+	}
+	if !filter.IncludeResults {
+		where += ` AND NOT EXISTS (SELECT 1 FROM results WHERE results.job_id = jobs.id)`
+	}
+
+	ids := []uuid.UUID{}
+	err := r.db.SelectContext(ctx, &ids, `DELETE FROM jobs WHERE `+where+` RETURNING id`, args...)
+	return ids, err
+}
+
 // jobRow is a helper struct for database scanning.
 type jobRow struct {
 	ID          uuid.UUID      `db:"id"`
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/guidiju-50/pandora/CONTROL/internal/models"
+)
+
+func TestJobFilterWhereClause(t *testing.T) {
+	projectID := uuid.New()
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	finished := []models.JobStatus{models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled}
+
+	tests := []struct {
+		name    string
+		filter  JobFilter
+		allowed []models.JobStatus
+		clause  string
+		args    int
+		ok      bool
+	}{
+		{
+			name:    "statuses only",
+			filter:  JobFilter{},
+			allowed: finished,
+			clause:  "status = ANY($1)",
+			args:    1,
+			ok:      true,
+		},
+		{
+			name:    "project",
+			filter:  JobFilter{ProjectID: &projectID},
+			allowed: finished,
+			clause:  "status = ANY($1) AND project_id = $2",
+			args:    2,
+			ok:      true,
+		},
+		{
+			name: "all criteria",
+			filter: JobFilter{
+				ProjectID:     &projectID,
+				Statuses:      []models.JobStatus{models.JobStatusFailed},
+				Types:         []models.JobType{models.JobTypeProcess},
+				CreatedAfter:  &after,
+				CreatedBefore: &before,
+			},
+			allowed: finished,
+			clause:  "status = ANY($1) AND project_id = $2 AND type = ANY($3) AND created_at >= $4 AND created_at < $5",
+			args:    5,
+			ok:      true,
+		},
+		{
+			name:    "dates without project",
+			filter:  JobFilter{CreatedAfter: &after, CreatedBefore: &before},
+			allowed: finished,
+			clause:  "status = ANY($1) AND created_at >= $2 AND created_at < $3",
+			args:    3,
+			ok:      true,
+		},
+		{
+			name:    "status outside allowed",
+			filter:  JobFilter{Statuses: []models.JobStatus{models.JobStatusRunning}},
+			allowed: finished,
+			ok:      false,
+		},
+		{
+			name:    "include results is not a condition",
+			filter:  JobFilter{ProjectID: &projectID, IncludeResults: true},
+			allowed: finished,
+			clause:  "status = ANY($1) AND project_id = $2",
+			args:    2,
+			ok:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args, ok := tt.filter.whereClause(tt.allowed)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if clause != tt.clause {
+				t.Errorf("clause = %q, want %q", clause, tt.clause)
+			}
+			if len(args) != tt.args {
+				t.Errorf("len(args) = %d, want %d", len(args), tt.args)
+			}
+		})
+	}
+}
+
+func TestJobFilterIsEmpty(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		filter JobFilter
+		want   bool
+	}{
+		{"zero", JobFilter{}, true},
+		{"include results only", JobFilter{IncludeResults: true}, true},
+		{"status", JobFilter{Statuses: []models.JobStatus{models.JobStatusFailed}}, false},
+		{"created after", JobFilter{CreatedAfter: &now}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.IsEmpty(); got != tt.want {
+				t.Errorf("IsEmpty() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package repository provides data access layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guidiju-50/pandora/CONTROL/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrVersionConflict is returned when another version of the same result
+// series was stored concurrently.
+var ErrVersionConflict = errors.New("result version already exists")
+
+// ResultRepository handles analysis result data operations.
+type ResultRepository struct {
+	db *sqlx.DB
+}
+
+// NewResultRepository creates a new result repository.
+func NewResultRepository(db *sqlx.DB) *ResultRepository {
+	return &ResultRepository{db: db}
+}
+
+// Create stores a result. The caller sets Version and ParentID; storing a
+// version that already exists for the series returns ErrVersionConflict.
+func (r *ResultRepository) Create(ctx context.Context, result *models.Result) error {
+	result.ID = uuid.New()
+	result.CreatedAt = time.Now()
+	if result.Version <= 0 {
+		result.Version = 1
+	}
+
+	dataJSON, err := json.Marshal(result.Data)
+	if err != nil {
+		return err
+	}
+	paramsJSON, err := json.Marshal(result.Parameters)
+	if err != nil {
+		return err
+	}
+	diffJSON, err := json.Marshal(result.Diff)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO results (id, experiment_id, job_id, type, comparison, version, parent_id, parameters, diff, data, file_path, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err = r.db.ExecContext(ctx, query,
+		result.ID, result.ExperimentID, result.JobID, result.Type, result.Comparison, result.Version, result.ParentID,
+		paramsJSON, diffJSON, dataJSON, result.FilePath, result.CreatedAt)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrVersionConflict
+	}
+	return err
+}
+
+// GetByID retrieves a result by ID.
+func (r *ResultRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Result, error) {
+	var row resultRow
+	query := `SELECT * FROM results WHERE id = $1`
+	err := r.db.GetContext(ctx, &row, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.toModel()
+}
+
+// Latest retrieves the most recent version of a result series.
+func (r *ResultRepository) Latest(ctx context.Context, experimentID uuid.UUID, resultType, comparison string) (*models.Result, error) {
+	var row resultRow
+	query := `
+		SELECT * FROM results
+		WHERE experiment_id = $1 AND type = $2 AND comparison = $3
+		ORDER BY version DESC LIMIT 1`
+	err := r.db.GetContext(ctx, &row, query, experimentID, resultType, comparison)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.toModel()
+}
+
+// ListVersions retrieves all versions of a result series, oldest first.
+func (r *ResultRepository) ListVersions(ctx context.Context, experimentID uuid.UUID, resultType, comparison string) ([]*models.Result, error) {
+	var rows []resultRow
+	query := `
+		SELECT * FROM results
+		WHERE experiment_id = $1 AND type = $2 AND comparison = $3
+		ORDER BY version ASC`
+	if err := r.db.SelectContext(ctx, &rows, query, experimentID, resultType, comparison); err != nil {
+		return nil, err
+	}
+	return toResultModels(rows), nil
+}
+
+// ListByExperiment retrieves the results of an experiment. When latestOnly is
+// set, only the most recent version of each series is returned.
+func (r *ResultRepository) ListByExperiment(ctx context.Context, experimentID uuid.UUID, latestOnly bool) ([]*models.Result, error) {
+	var rows []resultRow
+	query := `SELECT * FROM results WHERE experiment_id = $1 ORDER BY type, comparison, version DESC`
+	if latestOnly {
+		query = `
+			SELECT DISTINCT ON (type, comparison) * FROM results
+			WHERE experiment_id = $1
+			ORDER BY type, comparison, version DESC`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, experimentID); err != nil {
+		return nil, err
+	}
+	return toResultModels(rows), nil
+}
+
+// ExperimentProjectID returns the project that owns an experiment.
+func (r *ResultRepository) ExperimentProjectID(ctx context.Context, experimentID uuid.UUID) (uuid.UUID, error) {
+	var projectID uuid.UUID
+	query := `SELECT project_id FROM experiments WHERE id = $1`
+	err := r.db.GetContext(ctx, &projectID, query, experimentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrNotFound
+	}
+	return projectID, err
+}
+
+func toResultModels(rows []resultRow) []*models.Result {
+	results := make([]*models.Result, 0, len(rows))
+	for _, row := range rows {
+		result, err := row.toModel()
+		if err != nil {
+			continue
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+type resultRow struct {
+	ID           uuid.UUID      `db:"id"`
+	ExperimentID uuid.UUID      `db:"experiment_id"`
+	JobID        uuid.UUID      `db:"job_id"`
+	Type         string         `db:"type"`
+	Data         []byte         `db:"data"`
+	FilePath     sql.NullString `db:"file_path"`
+	CreatedAt    time.Time      `db:"created_at"`
+	Comparison   string         `db:"comparison"`
+	Version      int            `db:"version"`
+	ParentID     *uuid.UUID     `db:"parent_id"`
+	Parameters   []byte         `db:"parameters"`
+	Diff         []byte         `db:"diff"`
+}
+
+func (r *resultRow) toModel() (*models.Result, error) {
+	result := &models.Result{
+		ID:           r.ID,
+		ExperimentID: r.ExperimentID,
+		JobID:        r.JobID,
+		Type:         r.Type,
+		Comparison:   r.Comparison,
+		Version:      r.Version,
+		ParentID:     r.ParentID,
+		FilePath:     r.FilePath.String,
+		CreatedAt:    r.CreatedAt,
+	}
+
+	for _, field := range []struct {
+		raw []byte
+		dst *map[string]any
+	}{
+		{r.Data, &result.Data},
+		{r.Parameters, &result.Parameters},
+		{r.Diff, &result.Diff},
+	} {
+		if len(field.raw) > 0 {
+			if err := json.Unmarshal(field.raw, field.dst); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,101 @@
+// Package repository provides data access layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guidiju-50/pandora/CONTROL/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// SampleRepository handles sample data operations.
+type SampleRepository struct {
+	db *sqlx.DB
+}
+
+// NewSampleRepository creates a new sample repository.
+func NewSampleRepository(db *sqlx.DB) *SampleRepository {
+	return &SampleRepository{db: db}
+}
+
+// ListByExperiment retrieves the samples of an experiment.
+func (r *SampleRepository) ListByExperiment(ctx context.Context, experimentID uuid.UUID) ([]*models.Sample, error) {
+	var rows []sampleRow
+	query := `SELECT * FROM samples WHERE experiment_id = $1 ORDER BY condition, replicate, name`
+	if err := r.db.SelectContext(ctx, &rows, query, experimentID); err != nil {
+		return nil, err
+	}
+
+	samples := make([]*models.Sample, 0, len(rows))
+	for _, row := range rows {
+		sample, err := row.toModel()
+		if err != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// sampleRow is a helper struct for database scanning.
+type sampleRow struct {
+	ID           uuid.UUID      `db:"id"`
+	ExperimentID uuid.UUID      `db:"experiment_id"`
+	Name         string         `db:"name"`
+	Accession    sql.NullString `db:"accession"`
+	Condition    sql.NullString `db:"condition"`
+	Replicate    sql.NullInt64  `db:"replicate"`
+	Metadata     []byte         `db:"metadata"`
+	CreatedAt    time.Time      `db:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at"`
+}
+
+func (r *sampleRow) toModel() (*models.Sample, error) {
+	sample := &models.Sample{
+		ID:           r.ID,
+		ExperimentID: r.ExperimentID,
+		Name:         r.Name,
+		Accession:    r.Accession.String,
+		Condition:    r.Condition.String,
+		Replicate:    int(r.Replicate.Int64),
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+
+	if len(r.Metadata) > 0 {
+		metadata, err := stringMetadata(r.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		sample.Metadata = metadata
+	}
+
+	return sample, nil
+}
+
+// stringMetadata decodes a JSONB metadata object into string values. Strings
+// are kept as-is; numbers, booleans, arrays and objects keep their JSON text
+// so non-string metadata does not make the sample unreadable.
+func stringMetadata(raw []byte) (map[string]string, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]string, len(values))
+	for key, value := range values {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			metadata[key] = s
+			continue
+		}
+		if string(value) != "null" {
+			metadata[key] = string(value)
+		}
+	}
+	return metadata, nil
+}
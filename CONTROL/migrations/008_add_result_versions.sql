@@ -0,0 +1,27 @@
+-- Add versioning to results so re-runs of an analysis are linked to earlier runs
+ALTER TABLE results ADD COLUMN IF NOT EXISTS comparison VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE results ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES results(id) ON DELETE SET NULL;
+ALTER TABLE results ADD COLUMN IF NOT EXISTS parameters JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE results ADD COLUMN IF NOT EXISTS diff JSONB NOT NULL DEFAULT '{}';
+
+-- Number existing results within their series (oldest first) and link each
+-- to the previous one, so the unique index below can be built. Skipped once
+-- the index exists, so re-running the migration keeps recorded versions.
+WITH numbered AS (
+    SELECT id,
+           ROW_NUMBER() OVER series AS version,
+           LAG(id) OVER series AS parent_id
+    FROM results
+    WINDOW series AS (PARTITION BY experiment_id, type, comparison ORDER BY created_at, id)
+)
+UPDATE results
+SET version = numbered.version,
+    parent_id = numbered.parent_id
+FROM numbered
+WHERE results.id = numbered.id
+  AND NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'idx_results_series_version');
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_results_series_version ON results(experiment_id, type, comparison, version);
+CREATE INDEX IF NOT EXISTS idx_results_parent_id ON results(parent_id);
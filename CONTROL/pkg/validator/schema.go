@@ -0,0 +1,274 @@
+// Package validator provides JSON Schema based validation of free-form input.
+package validator
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema (draft-07) used to describe job inputs.
+// It marshals to a standard schema document so clients can generate forms.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+
+	pattern *regexp.Regexp // Compiled Pattern, set by Compile
+}
+
+// Compile compiles the patterns of the schema and its subschemas. It must be
+// called once after the schema is built and before it is used to validate.
+func (s *Schema) Compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for name, prop := range s.Properties {
+		if err := prop.Compile(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, alt := range s.AnyOf {
+		if err := alt.Compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.Compile(); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	}
+	return nil
+}
+
+// MustCompile is like Compile but panics on an invalid pattern. It returns
+// the schema so it can wrap package-level schema definitions.
+func MustCompile(s *Schema) *Schema {
+	if err := s.Compile(); err != nil {
+		panic("validator: " + err.Error())
+	}
+	return s
+}
+
+// FieldError describes a validation failure of a single field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Validate checks a decoded JSON object against the schema and returns one
+// error per offending field, ordered by field name.
+func (s *Schema) Validate(input map[string]any) []FieldError {
+	var errs []FieldError
+	s.validateObject("", input, &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func (s *Schema) validateObject(path string, obj map[string]any, errs *[]FieldError) {
+	for _, name := range s.Required {
+		if value, ok := obj[name]; !ok || value == nil {
+			*errs = append(*errs, FieldError{joinPath(path, name), "is required"})
+		}
+	}
+
+	if len(s.AnyOf) > 0 && !s.anyOfSatisfied(obj) {
+		*errs = append(*errs, FieldError{rootPath(path), "requires one of: " + strings.Join(s.anyOfFields(), ", ")})
+	}
+
+	for name, value := range obj {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				msg := "unknown field"
+				if suggestion := s.closestProperty(name); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				*errs = append(*errs, FieldError{joinPath(path, name), msg})
+			}
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		prop.validateValue(joinPath(path, name), value, errs)
+	}
+}
+
+func (s *Schema) validateValue(path string, value any, errs *[]FieldError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{path, fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.Pattern != "" {
+			if s.pattern == nil {
+				fail("pattern %s is not compiled", s.Pattern)
+			} else if !s.pattern.MatchString(str) {
+				fail("must match pattern %s", s.Pattern)
+			}
+		}
+	case "integer", "number":
+		num, ok := value.(float64)
+		if !ok {
+			fail("must be a %s", s.Type)
+			return
+		}
+		if s.Type == "integer" && num != math.Trunc(num) {
+			fail("must be an integer")
+		}
+		if s.Minimum != nil && num < *s.Minimum {
+			fail("must be >= %g", *s.Minimum)
+		}
+		if s.Maximum != nil && num > *s.Maximum {
+			fail("must be <= %g", *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validateValue(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		s.validateObject(path, obj, errs)
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		fail("must be one of %v", s.Enum)
+	}
+}
+
+func (s *Schema) anyOfSatisfied(obj map[string]any) bool {
+	for _, alt := range s.AnyOf {
+		satisfied := true
+		for _, name := range alt.Required {
+			if value, ok := obj[name]; !ok || value == nil {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) anyOfFields() []string {
+	fields := make([]string, 0, len(s.AnyOf))
+	for _, alt := range s.AnyOf {
+		fields = append(fields, strings.Join(alt.Required, "+"))
+	}
+	return fields
+}
+
+// closestProperty returns the declared property closest to name by edit
+// distance, or "" when none is plausibly a typo.
+func (s *Schema) closestProperty(name string) string {
+	props := make([]string, 0, len(s.Properties))
+	for prop := range s.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	best, bestDist := "", 3
+	for _, prop := range props {
+		if d := levenshtein(strings.ToLower(name), prop); d < bestDist {
+			best, bestDist = prop, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "input"
+	}
+	return path
+}
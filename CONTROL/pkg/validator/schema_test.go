@@ -0,0 +1,46 @@
+package validator
+
+import "testing"
+
+func TestSchemaPattern(t *testing.T) {
+	closed := false
+	schema := MustCompile(&Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"window": {Type: "string", Pattern: `^[0-9]+:[0-9]+$`},
+			"runs":   {Type: "array", Items: &Schema{Type: "string", Pattern: `^SRR[0-9]+$`}},
+		},
+		AdditionalProperties: &closed,
+	})
+
+	tests := []struct {
+		name   string
+		input  map[string]any
+		fields []string
+	}{
+		{"valid", map[string]any{"window": "4:15", "runs": []any{"SRR1"}}, nil},
+		{"property mismatch", map[string]any{"window": "4-15"}, []string{"window"}},
+		{"item mismatch", map[string]any{"runs": []any{"SRR1", "ERR2"}}, []string{"runs[1]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.Validate(tt.input)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("got errors %v, want fields %v", errs, tt.fields)
+			}
+			for i, field := range tt.fields {
+				if errs[i].Field != field {
+					t.Errorf("error %d on %q, want %q", i, errs[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaCompileInvalidPattern(t *testing.T) {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{"id": {Type: "string", Pattern: "("}}}
+	if err := schema.Compile(); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
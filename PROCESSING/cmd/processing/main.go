@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,8 +19,10 @@ import (
 	"github.com/guidiju-50/pandora/PROCESSING/internal/download"
 	"github.com/guidiju-50/pandora/PROCESSING/internal/etl"
 	"github.com/guidiju-50/pandora/PROCESSING/internal/jobs"
+	"github.com/guidiju-50/pandora/PROCESSING/internal/models"
 	"github.com/guidiju-50/pandora/PROCESSING/internal/scraper"
 	"github.com/guidiju-50/pandora/PROCESSING/internal/trimming"
+	"github.com/guidiju-50/pandora/SHARED/discovery"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -41,12 +45,25 @@ func main() {
 		cfg = &config.Config{}
 	}
 
+	// Initialize service registry for inter-module calls
+	registry := discovery.NewRegistry(discovery.Config{
+		HealthInterval: cfg.Discovery.HealthInterval,
+		HealthTimeout:  cfg.Discovery.HealthTimeout,
+	}, logger)
+	registry.Register(discovery.ServiceControl, serviceURLs(cfg.Control.URL, "CONTROL_API_URL", "http://localhost:8080")...)
+
+	registryCtx, stopRegistry := context.WithCancel(context.Background())
+	defer stopRegistry()
+	registry.Start(registryCtx)
+
 	// Initialize components
 	ncbiScraper := scraper.NewNCBIScraper(cfg.Scraper.NCBI, logger)
-	loader := etl.NewLoader(cfg.Control, logger)
+	loader := etl.NewLoader(cfg.Control, registry, logger)
 	pipeline := etl.NewPipeline(cfg.ETL, ncbiScraper, loader, logger)
 	trimmomatic := trimming.NewTrimmomatic(cfg.Trimmomatic, logger)
+	fastp := trimming.NewFastp(cfg.Fastp, logger)
 	qualityChecker := trimming.NewQualityChecker(logger)
+	cropper := trimming.NewCropper(logger)
 
 	// Initialize SRA downloader
 	sraDownloader := download.NewSRADownloader(download.Config{
@@ -55,13 +72,21 @@ func main() {
 		FasterqDump: getEnvOrDefault("FASTERQ_DUMP", "fasterq-dump"),
 		Prefetch:    getEnvOrDefault("PREFETCH", "prefetch"),
 		Threads:     4,
+
+		ENAPortalURL:   cfg.Download.ENAPortalURL,
+		ENAHost:        cfg.Download.ENAHost,
+		Protocol:       cfg.Download.Protocol,
+		APITimeout:     cfg.Download.APITimeout,
+		SkipSRAToolkit: cfg.Download.ENAOnly,
 	}, logger)
 
-	// Initialize job manager
-	jobManager := jobs.NewManager()
+	// Initialize job manager; its jobs are cancelled on shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobManager := jobs.NewManager(jobsCtx)
 
 	// Create HTTP server
-	router := setupRouter(logger, pipeline, trimmomatic, qualityChecker, sraDownloader, jobManager)
+	router := setupRouter(logger, pipeline, trimmomatic, fastp, qualityChecker, cropper, sraDownloader, jobManager, registry)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
@@ -84,6 +109,7 @@ func main() {
 	<-quit
 
 	logger.Info("shutting down server...")
+	stopJobs()
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -129,9 +155,12 @@ func setupRouter(
 	logger *zap.Logger,
 	pipeline *etl.Pipeline,
 	trimmomatic *trimming.Trimmomatic,
+	fastp *trimming.Fastp,
 	qualityChecker *trimming.QualityChecker,
+	cropper *trimming.Cropper,
 	sraDownloader *download.SRADownloader,
 	jobManager *jobs.Manager,
+	registry *discovery.Registry,
 ) *gin.Engine {
 	// Set Gin mode based on environment
 	if os.Getenv("ENV") == "production" {
@@ -161,18 +190,29 @@ func setupRouter(
 		api.GET("/jobs/:id/progress", handleJobProgress(jobManager))
 		api.POST("/jobs/:id/cancel", handleCancelJob(jobManager))
 
+		// Bulk job operations by filter
+		api.POST("/jobs/bulk/cancel", handleBulkCancelJobs(jobManager))
+		api.POST("/jobs/bulk/retry", handleBulkRetryJobs(jobManager))
+		api.POST("/jobs/bulk/delete", handleBulkDeleteJobs(jobManager))
+
 		// Job actions
 		jobsGroup := api.Group("/jobs")
 		{
 			jobsGroup.POST("/scrape", handleScrape(logger, pipeline))
 			jobsGroup.POST("/download", handleDownloadAsync(logger, sraDownloader, jobManager))
-			jobsGroup.POST("/process", handleProcess(logger, trimmomatic, qualityChecker))
+			jobsGroup.POST("/process", handleProcess(logger, trimmomatic, qualityChecker, cropper))
 			jobsGroup.POST("/etl", handleETL(logger, pipeline))
-			jobsGroup.POST("/full-pipeline", handleFullPipelineAsync(logger, sraDownloader, trimmomatic, qualityChecker, jobManager))
+			jobsGroup.POST("/full-pipeline", handleFullPipelineAsync(logger, sraDownloader, trimmomatic, fastp, qualityChecker, cropper, jobManager))
 		}
 
 		// Quality check
 		api.POST("/quality", handleQualityCheck(logger, qualityChecker))
+
+		// Read length harmonization
+		api.POST("/harmonize", handleHarmonize(logger, cropper))
+
+		// Service discovery
+		api.GET("/services", handleListServices(registry))
 	}
 
 	return router
@@ -268,9 +308,11 @@ type ProcessRequest struct {
 	Trailing      int    `json:"trailing"`
 	SlidingWindow string `json:"sliding_window"`
 	MinLen        int    `json:"min_len"`
+	CropLength    int    `json:"crop_length"`  // Crop trimmed reads to this length (0 disables)
+	KeepShorter   bool   `json:"keep_shorter"` // Keep reads shorter than crop_length
 }
 
-func handleProcess(logger *zap.Logger, trimmomatic *trimming.Trimmomatic, qc *trimming.QualityChecker) gin.HandlerFunc {
+func handleProcess(logger *zap.Logger, trimmomatic *trimming.Trimmomatic, qc *trimming.QualityChecker, cropper *trimming.Cropper) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req ProcessRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -313,10 +355,22 @@ func handleProcess(logger *zap.Logger, trimmomatic *trimming.Trimmomatic, qc *tr
 			}
 		}
 
+		// Optional fixed-length crop after trimming
+		var cropReport *models.CropReport
+		if req.CropLength > 0 {
+			cropReport, err = cropper.Crop(ctx, trimmedCropOptions(result.OutputFiles, req.OutputDir, req.CropLength, req.KeepShorter))
+			if err != nil {
+				logger.Error("cropping failed", zap.Error(err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"status":     "completed",
 			"result":     result.ToModel(),
 			"comparison": comparison,
+			"crop":       cropReport,
 		})
 	}
 }
@@ -398,13 +452,87 @@ type FullPipelineRequest struct {
 	Trailing      int    `json:"trailing"`
 	SlidingWindow string `json:"sliding_window"`
 	MinLen        int    `json:"min_len"`
+	CropLength    int    `json:"crop_length"`  // Crop trimmed reads to this length (0 disables)
+	KeepShorter   bool   `json:"keep_shorter"` // Keep reads shorter than crop_length
+	Stream        bool   `json:"stream"`       // Stream ENA reads straight into the trimmer
+	Trimmer       string `json:"trimmer" binding:"omitempty,oneof=trimmomatic fastp"` // Default trimmomatic
+}
+
+// trimmer returns the trimming tool chosen by the request.
+func (req FullPipelineRequest) trimmer(trimmomatic *trimming.Trimmomatic, fastp *trimming.Fastp) trimming.Trimmer {
+	if req.Trimmer == "fastp" {
+		return fastp
+	}
+	return trimmomatic
+}
+
+// trimmerName returns the display name of the chosen trimming tool.
+func (req FullPipelineRequest) trimmerName() string {
+	if req.Trimmer == "fastp" {
+		return "fastp"
+	}
+	return "Trimmomatic"
+}
+
+// trimOptions builds trimming options for a full pipeline request.
+func (req FullPipelineRequest) trimOptions(files []string, outputDir string) trimming.Options {
+	opts := trimming.Options{
+		InputFile1:    files[0],
+		OutputDir:     outputDir,
+		Leading:       req.Leading,
+		Trailing:      req.Trailing,
+		SlidingWindow: req.SlidingWindow,
+		MinLen:        req.MinLen,
+	}
+	if len(files) > 1 {
+		opts.InputFile2 = files[1]
+	}
+	return opts
+}
+
+// streamAndTrim streams the reads of an ENA run through named pipes into
+// the trimmer, so only trimmed reads are written to disk.
+func streamAndTrim(
+	ctx context.Context,
+	downloader *download.SRADownloader,
+	trimmer trimming.Trimmer,
+	req FullPipelineRequest,
+	progressFn func(downloaded, total int64),
+) (*download.Stream, *trimming.Result, error) {
+	stream, err := downloader.OpenENAStream(ctx, req.Accession, progressFn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("download failed: %w", err)
+	}
+
+	outputDir := stream.OutputDir + "/trimmed"
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		stream.Close(err)
+		return stream, nil, err
+	}
+
+	opts := req.trimOptions(stream.Pipes, outputDir)
+	opts.Streamed = true
+	trimResult, err := trimmer.Run(ctx, opts)
+	streamErr := stream.Close(err)
+	if err != nil {
+		return stream, nil, fmt.Errorf("%s failed: %w", strings.ToLower(req.trimmerName()), err)
+	}
+	// A truncated download can look like a clean EOF to the trimmer, so a
+	// stream error fails the run even when trimming succeeded
+	if streamErr != nil {
+		return stream, nil, fmt.Errorf("download failed: %w", streamErr)
+	}
+
+	return stream, trimResult, nil
 }
 
 func handleFullPipeline(
 	logger *zap.Logger,
 	downloader *download.SRADownloader,
 	trimmomatic *trimming.Trimmomatic,
+	fastp *trimming.Fastp,
 	qc *trimming.QualityChecker,
+	cropper *trimming.Cropper,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req FullPipelineRequest
@@ -414,7 +542,42 @@ func handleFullPipeline(
 		}
 
 		ctx := c.Request.Context()
-		logger.Info("starting full pipeline", zap.String("accession", req.Accession))
+		logger.Info("starting full pipeline", zap.String("accession", req.Accession), zap.Bool("stream", req.Stream))
+
+		if req.Stream {
+			stream, trimResult, err := streamAndTrim(ctx, downloader, req.trimmer(trimmomatic, fastp), req, nil)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":    err.Error(),
+					"step":     "streaming",
+					"download": stream,
+				})
+				return
+			}
+
+			var cropReport *models.CropReport
+			if req.CropLength > 0 {
+				cropOpts := trimmedCropOptions(trimResult.OutputFiles, stream.OutputDir, req.CropLength, req.KeepShorter)
+				cropOpts.SampleID = req.Accession
+				cropReport, err = cropper.Crop(ctx, cropOpts)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{
+						"error":    fmt.Sprintf("cropping failed: %v", err),
+						"step":     "cropping",
+						"download": stream,
+					})
+					return
+				}
+			}
+
+			c.JSON(http.StatusOK, gin.H{
+				"status":   "completed",
+				"download": stream,
+				"trimming": trimResult.ToModel(),
+				"crop":     cropReport,
+			})
+			return
+		}
 
 		// Step 1: Download (SmartDownload automatically uses best available method)
 		downloadResult, err := downloader.SmartDownload(ctx, req.Accession)
@@ -438,26 +601,12 @@ func handleFullPipeline(
 		// Step 2: Quality check before trimming
 		beforeQuality, _ := qc.AnalyzeFile(downloadResult.Files[0])
 
-		// Step 3: Trimmomatic processing
-		outputDir := downloadResult.OutputDir + "/trimmed"
-		opts := trimming.Options{
-			InputFile1:    downloadResult.Files[0],
-			OutputDir:     outputDir,
-			Leading:       req.Leading,
-			Trailing:      req.Trailing,
-			SlidingWindow: req.SlidingWindow,
-			MinLen:        req.MinLen,
-		}
-
-		// Check if paired-end
-		if len(downloadResult.Files) > 1 {
-			opts.InputFile2 = downloadResult.Files[1]
-		}
-
-		trimResult, err := trimmomatic.Run(ctx, opts)
+		// Step 3: Trimming
+		opts := req.trimOptions(downloadResult.Files, downloadResult.OutputDir+"/trimmed")
+		trimResult, err := req.trimmer(trimmomatic, fastp).Run(ctx, opts)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":    fmt.Sprintf("trimmomatic failed: %v", err),
+				"error":    fmt.Sprintf("%s failed: %v", strings.ToLower(req.trimmerName()), err),
 				"step":     "trimming",
 				"download": downloadResult,
 			})
@@ -473,11 +622,103 @@ func handleFullPipeline(
 			}
 		}
 
+		// Step 5: Optional fixed-length crop
+		var cropReport *models.CropReport
+		if req.CropLength > 0 {
+			cropOpts := trimmedCropOptions(trimResult.OutputFiles, downloadResult.OutputDir, req.CropLength, req.KeepShorter)
+			cropOpts.SampleID = req.Accession
+			cropReport, err = cropper.Crop(ctx, cropOpts)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":    fmt.Sprintf("cropping failed: %v", err),
+					"step":     "cropping",
+					"download": downloadResult,
+				})
+				return
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"status":             "completed",
 			"download":           downloadResult,
 			"trimming":           trimResult.ToModel(),
 			"quality_comparison": comparison,
+			"crop":               cropReport,
+		})
+	}
+}
+
+// trimmedCropOptions builds crop options for the reads produced by the trimmer.
+// Cropped reads always go to the cropped/ subdirectory of the job directory.
+func trimmedCropOptions(trimmedFiles []string, jobDir string, length int, keepShorter bool) trimming.CropOptions {
+	opts := trimming.CropOptions{
+		OutputDir:   filepath.Join(jobDir, "cropped"),
+		Length:      length,
+		KeepShorter: keepShorter,
+	}
+	if len(trimmedFiles) > 0 {
+		opts.InputFile1 = trimmedFiles[0]
+	}
+	if len(trimmedFiles) > 1 {
+		opts.InputFile2 = trimmedFiles[1]
+	}
+	return opts
+}
+
+// HarmonizeRequest represents a read length harmonization request.
+type HarmonizeRequest struct {
+	Samples     []trimming.HarmonizeSample `json:"samples" binding:"required"`
+	Length      int                        `json:"length"` // 0 uses the shortest read length across samples
+	KeepShorter bool                       `json:"keep_shorter"`
+}
+
+func handleHarmonize(logger *zap.Logger, cropper *trimming.Cropper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req HarmonizeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if len(req.Samples) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at least one sample required"})
+			return
+		}
+		for _, sample := range req.Samples {
+			if sample.InputFile1 == "" || sample.OutputDir == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "each sample requires input_file_1 and output_dir"})
+				return
+			}
+		}
+
+		report, err := cropper.Harmonize(c.Request.Context(), req.Samples, req.Length, req.KeepShorter)
+		if err != nil {
+			logger.Error("harmonization failed", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "completed",
+			"report": report,
+		})
+	}
+}
+
+// serviceURLs returns the configured replica URLs of a service, falling back
+// to the environment variable or its default when the configuration did not
+// provide any (e.g. when the config file failed to load).
+func serviceURLs(configured, envKey, defaultURL string) []string {
+	if urls := discovery.ParseURLs(configured); len(urls) > 0 {
+		return urls
+	}
+	return discovery.ParseURLs(getEnvOrDefault(envKey, defaultURL))
+}
+
+func handleListServices(registry *discovery.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"services": registry.Services(),
 		})
 	}
 }
@@ -558,6 +799,86 @@ func handleCancelJob(jobManager *jobs.Manager) gin.HandlerFunc {
 	}
 }
 
+// BulkJobsRequest selects the jobs affected by a bulk operation.
+type BulkJobsRequest struct {
+	Type          string        `json:"type"`
+	Status        []jobs.Status `json:"status"`
+	CreatedAfter  *time.Time    `json:"created_after"`
+	CreatedBefore *time.Time    `json:"created_before"`
+}
+
+// bindJobFilter parses a bulk request into a job filter.
+func bindJobFilter(c *gin.Context) (jobs.Filter, bool) {
+	var req BulkJobsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return jobs.Filter{}, false
+	}
+
+	filter := jobs.Filter{
+		Type:          req.Type,
+		Statuses:      req.Status,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+	}
+	if filter.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one filter is required"})
+		return filter, false
+	}
+	return filter, true
+}
+
+// handleBulkCancelJobs cancels all pending or running jobs matching a filter.
+func handleBulkCancelJobs(jobManager *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindJobFilter(c)
+		if !ok {
+			return
+		}
+
+		ids := jobManager.CancelJobs(filter)
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "cancelled",
+			"affected": len(ids),
+			"job_ids":  ids,
+		})
+	}
+}
+
+// handleBulkRetryJobs re-runs all failed or cancelled jobs matching a filter.
+func handleBulkRetryJobs(jobManager *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindJobFilter(c)
+		if !ok {
+			return
+		}
+
+		ids := jobManager.RetryJobs(filter)
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":   "pending",
+			"affected": len(ids),
+			"job_ids":  ids,
+		})
+	}
+}
+
+// handleBulkDeleteJobs removes all finished jobs matching a filter.
+func handleBulkDeleteJobs(jobManager *jobs.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := bindJobFilter(c)
+		if !ok {
+			return
+		}
+
+		ids := jobManager.DeleteJobs(filter)
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "deleted",
+			"affected": len(ids),
+			"job_ids":  ids,
+		})
+	}
+}
+
 // handleJobProgress returns Server-Sent Events for job progress.
 func handleJobProgress(jobManager *jobs.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -661,7 +982,9 @@ func handleFullPipelineAsync(
 	logger *zap.Logger,
 	downloader *download.SRADownloader,
 	trimmomatic *trimming.Trimmomatic,
+	fastp *trimming.Fastp,
 	qc *trimming.QualityChecker,
+	cropper *trimming.Cropper,
 	jobManager *jobs.Manager,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -678,12 +1001,20 @@ func handleFullPipelineAsync(
 			"trailing":       req.Trailing,
 			"sliding_window": req.SlidingWindow,
 			"min_len":        req.MinLen,
+			"crop_length":    req.CropLength,
+			"keep_shorter":   req.KeepShorter,
+			"stream":         req.Stream,
+			"trimmer":        req.Trimmer,
 		}
 		jobID := jobManager.CreateJob("full-pipeline", input)
 
 		// Run async
 		jobManager.RunAsync(context.Background(), jobID, func(ctx context.Context, updateProgress func(int, string)) (map[string]interface{}, error) {
-			logger.Info("starting full pipeline job", zap.String("job_id", jobID), zap.String("accession", req.Accession))
+			logger.Info("starting full pipeline job", zap.String("job_id", jobID), zap.String("accession", req.Accession), zap.Bool("stream", req.Stream))
+
+			if req.Stream {
+				return runStreamingPipeline(ctx, downloader, req.trimmer(trimmomatic, fastp), cropper, req, updateProgress)
+			}
 
 			// Step 1: Download (5-50%) with progress
 			updateProgress(5, fmt.Sprintf("Starting download of %s...", req.Accession))
@@ -707,26 +1038,13 @@ func handleFullPipelineAsync(
 			// Step 2: Quality check before trimming
 			beforeQuality, _ := qc.AnalyzeFile(downloadResult.Files[0])
 
-			updateProgress(55, "Starting Trimmomatic processing...")
-
-			// Step 3: Trimmomatic processing (50-90%)
-			outputDir := downloadResult.OutputDir + "/trimmed"
-			opts := trimming.Options{
-				InputFile1:    downloadResult.Files[0],
-				OutputDir:     outputDir,
-				Leading:       req.Leading,
-				Trailing:      req.Trailing,
-				SlidingWindow: req.SlidingWindow,
-				MinLen:        req.MinLen,
-			}
-
-			if len(downloadResult.Files) > 1 {
-				opts.InputFile2 = downloadResult.Files[1]
-			}
+			updateProgress(55, fmt.Sprintf("Starting %s processing...", req.trimmerName()))
 
-			trimResult, err := trimmomatic.Run(ctx, opts)
+			// Step 3: Trimming (50-90%)
+			opts := req.trimOptions(downloadResult.Files, downloadResult.OutputDir+"/trimmed")
+			trimResult, err := req.trimmer(trimmomatic, fastp).Run(ctx, opts)
 			if err != nil {
-				return nil, fmt.Errorf("trimmomatic failed: %w", err)
+				return nil, fmt.Errorf("%s failed: %w", strings.ToLower(req.trimmerName()), err)
 			}
 
 			updateProgress(90, "Trimming completed, analyzing quality...")
@@ -740,12 +1058,26 @@ func handleFullPipelineAsync(
 				}
 			}
 
+			// Step 5: Optional fixed-length crop
+			var cropReport *models.CropReport
+			if req.CropLength > 0 {
+				updateProgress(95, fmt.Sprintf("Cropping reads to %d bp...", req.CropLength))
+
+				cropOpts := trimmedCropOptions(trimResult.OutputFiles, downloadResult.OutputDir, req.CropLength, req.KeepShorter)
+				cropOpts.SampleID = req.Accession
+				cropReport, err = cropper.Crop(ctx, cropOpts)
+				if err != nil {
+					return nil, fmt.Errorf("cropping failed: %w", err)
+				}
+			}
+
 			updateProgress(100, "Pipeline completed successfully")
 
 			return map[string]interface{}{
 				"download":           downloadResult,
 				"trimming":           trimResult.ToModel(),
 				"quality_comparison": comparison,
+				"crop":               cropReport,
 			}, nil
 		})
 
@@ -757,3 +1089,57 @@ func handleFullPipelineAsync(
 		})
 	}
 }
+
+// runStreamingPipeline is the async full pipeline in streaming mode. Download
+// and trimming overlap, so progress (5-90%) follows the bytes streamed. The
+// before/after quality comparison is skipped: the raw reads never hit disk.
+func runStreamingPipeline(
+	ctx context.Context,
+	downloader *download.SRADownloader,
+	trimmer trimming.Trimmer,
+	cropper *trimming.Cropper,
+	req FullPipelineRequest,
+	updateProgress func(int, string),
+) (map[string]interface{}, error) {
+	updateProgress(5, fmt.Sprintf("Streaming %s into %s...", req.Accession, req.trimmerName()))
+
+	streamProgress := func(downloaded, total int64) {
+		if total <= 0 {
+			updateProgress(5, fmt.Sprintf("Streamed %.1f MB", float64(downloaded)/1024/1024))
+			return
+		}
+		progress := 5 + int(float64(downloaded)/float64(total)*85)
+		if progress > 90 {
+			progress = 90
+		}
+		updateProgress(progress, fmt.Sprintf("Streamed and trimmed %.1f / %.1f MB",
+			float64(downloaded)/1024/1024, float64(total)/1024/1024))
+	}
+
+	stream, trimResult, err := streamAndTrim(ctx, downloader, trimmer, req, streamProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	updateProgress(90, "Streaming and trimming completed")
+
+	var cropReport *models.CropReport
+	if req.CropLength > 0 {
+		updateProgress(95, fmt.Sprintf("Cropping reads to %d bp...", req.CropLength))
+
+		cropOpts := trimmedCropOptions(trimResult.OutputFiles, stream.OutputDir, req.CropLength, req.KeepShorter)
+		cropOpts.SampleID = req.Accession
+		cropReport, err = cropper.Crop(ctx, cropOpts)
+		if err != nil {
+			return nil, fmt.Errorf("cropping failed: %w", err)
+		}
+	}
+
+	updateProgress(100, "Pipeline completed successfully")
+
+	return map[string]interface{}{
+		"download": stream,
+		"trimming": trimResult.ToModel(),
+		"crop":     cropReport,
+	}, nil
+}
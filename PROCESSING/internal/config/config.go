@@ -12,8 +12,11 @@ type Config struct {
 	Server      ServerConfig      `mapstructure:"server"`
 	Scraper     ScraperConfig     `mapstructure:"scraper"`
 	Trimmomatic TrimmoConfig      `mapstructure:"trimmomatic"`
+	Fastp       FastpConfig       `mapstructure:"fastp"`
 	ETL         ETLConfig         `mapstructure:"etl"`
 	Control     ControlAPIConfig  `mapstructure:"control"`
+	Discovery   DiscoveryConfig   `mapstructure:"discovery"`
+	Download    DownloadConfig    `mapstructure:"download"`
 	Directories DirectoriesConfig `mapstructure:"directories"`
 }
 
@@ -50,6 +53,12 @@ type TrimmoConfig struct {
 	MinLen        int    `mapstructure:"min_len"`
 }
 
+// FastpConfig holds fastp configuration.
+type FastpConfig struct {
+	Path    string `mapstructure:"path"`
+	Threads int    `mapstructure:"threads"`
+}
+
 // ETLConfig holds ETL pipeline configuration.
 type ETLConfig struct {
 	BatchSize     int `mapstructure:"batch_size"`
@@ -59,11 +68,29 @@ type ETLConfig struct {
 
 // ControlAPIConfig holds CONTROL module API configuration.
 type ControlAPIConfig struct {
-	URL     string        `mapstructure:"url"`
+	URL     string        `mapstructure:"url"` // Comma-separated replica URLs
 	Timeout time.Duration `mapstructure:"timeout"`
 	APIKey  string        `mapstructure:"api_key"`
 }
 
+// DiscoveryConfig holds service discovery configuration.
+type DiscoveryConfig struct {
+	HealthInterval time.Duration `mapstructure:"health_interval"`
+	HealthTimeout  time.Duration `mapstructure:"health_timeout"`
+}
+
+// DownloadConfig holds read download configuration. Point the ENA settings
+// at an internal mirror for air-gapped deployments. They only apply to ENA
+// downloads: fasterq-dump is tried first and still reaches NCBI unless
+// ENAOnly is set, so air-gapped deployments must set ena_only too.
+type DownloadConfig struct {
+	ENAPortalURL string        `mapstructure:"ena_portal_url"` // ENA Portal API base; not used by fasterq-dump
+	ENAHost      string        `mapstructure:"ena_host"`       // Replaces the host of ENA FASTQ URLs (optional); not used by fasterq-dump
+	Protocol     string        `mapstructure:"protocol"`       // Scheme for ENA FASTQ downloads: https or http
+	APITimeout   time.Duration `mapstructure:"api_timeout"`    // Timeout of ENA Portal API queries
+	ENAOnly      bool          `mapstructure:"ena_only"`       // Skip fasterq-dump (NCBI) and download from ENA only
+}
+
 // DirectoriesConfig holds directory paths configuration.
 type DirectoriesConfig struct {
 	Data   string `mapstructure:"data"`
@@ -120,6 +147,10 @@ func setDefaults() {
 	viper.SetDefault("trimmomatic.sliding_window", "4:15")
 	viper.SetDefault("trimmomatic.min_len", 36)
 
+	// fastp defaults
+	viper.SetDefault("fastp.path", "fastp")
+	viper.SetDefault("fastp.threads", 4)
+
 	// ETL defaults
 	viper.SetDefault("etl.batch_size", 1000)
 	viper.SetDefault("etl.retry_attempts", 3)
@@ -129,6 +160,15 @@ func setDefaults() {
 	viper.SetDefault("control.url", "http://localhost:8080")
 	viper.SetDefault("control.timeout", "30s")
 
+	// Service discovery defaults
+	viper.SetDefault("discovery.health_interval", "15s")
+	viper.SetDefault("discovery.health_timeout", "3s")
+
+	// Download defaults
+	viper.SetDefault("download.ena_portal_url", "https://www.ebi.ac.uk/ena/portal/api")
+	viper.SetDefault("download.protocol", "https")
+	viper.SetDefault("download.api_timeout", "60s")
+
 	// Directory defaults
 	viper.SetDefault("directories.data", "/data/processing")
 	viper.SetDefault("directories.temp", "/tmp/processing")
@@ -140,8 +180,16 @@ func bindEnvVariables() {
 	viper.BindEnv("scraper.ncbi.api_key", "NCBI_API_KEY")
 	viper.BindEnv("trimmomatic.jar_path", "TRIMMOMATIC_JAR")
 	viper.BindEnv("trimmomatic.adapters_path", "TRIMMOMATIC_ADAPTERS")
+	viper.BindEnv("fastp.path", "FASTP_PATH")
 	viper.BindEnv("control.url", "CONTROL_API_URL")
 	viper.BindEnv("control.api_key", "CONTROL_API_KEY")
+	viper.BindEnv("discovery.health_interval", "SERVICE_HEALTH_INTERVAL")
+	viper.BindEnv("discovery.health_timeout", "SERVICE_HEALTH_TIMEOUT")
+	viper.BindEnv("download.ena_portal_url", "ENA_PORTAL_URL")
+	viper.BindEnv("download.ena_host", "ENA_HOST")
+	viper.BindEnv("download.protocol", "DOWNLOAD_PROTOCOL")
+	viper.BindEnv("download.api_timeout", "ENA_API_TIMEOUT")
+	viper.BindEnv("download.ena_only", "ENA_ONLY")
 	viper.BindEnv("directories.data", "DATA_DIR")
 	viper.BindEnv("directories.temp", "TEMP_DIR")
 	viper.BindEnv("directories.output", "OUTPUT_DIR")
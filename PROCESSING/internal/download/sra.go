@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,12 +20,17 @@ import (
 
 // SRADownloader handles downloading SRA files using SRA Toolkit.
 type SRADownloader struct {
-	outputDir     string
-	tempDir       string
-	fasterqDump   string
-	prefetch      string
-	threads       int
-	logger        *zap.Logger
+	outputDir    string
+	tempDir      string
+	fasterqDump  string
+	prefetch     string
+	threads      int
+	enaPortalURL string
+	enaHost      string
+	protocol     string
+	apiTimeout   time.Duration
+	skipToolkit  bool
+	logger       *zap.Logger
 }
 
 // Config holds SRA downloader configuration.
@@ -34,8 +40,21 @@ type Config struct {
 	FasterqDump string // Path to fasterq-dump binary
 	Prefetch    string // Path to prefetch binary
 	Threads     int
+
+	// ENA access; defaults target the public EBI service.
+	ENAPortalURL   string        // Portal API base, e.g. https://www.ebi.ac.uk/ena/portal/api
+	ENAHost        string        // Host (optionally scheme and path prefix) replacing ftp.sra.ebi.ac.uk in FASTQ URLs
+	Protocol       string        // Scheme for FASTQ downloads: https (default) or http
+	APITimeout     time.Duration // Portal API request timeout
+	SkipSRAToolkit bool          // Go straight to ENA instead of trying fasterq-dump first
 }
 
+// DefaultENAPortalURL is the public ENA Portal API.
+const DefaultENAPortalURL = "https://www.ebi.ac.uk/ena/portal/api"
+
+// enaFileReportFields are the filereport fields needed to download FASTQ files.
+const enaFileReportFields = "run_accession,fastq_ftp,fastq_md5,fastq_bytes"
+
 // NewSRADownloader creates a new SRA downloader.
 func NewSRADownloader(cfg Config, logger *zap.Logger) *SRADownloader {
 	fasterqDump := cfg.FasterqDump
@@ -53,13 +72,33 @@ func NewSRADownloader(cfg Config, logger *zap.Logger) *SRADownloader {
 		threads = 4
 	}
 
+	enaPortalURL := strings.TrimRight(cfg.ENAPortalURL, "/")
+	if enaPortalURL == "" {
+		enaPortalURL = DefaultENAPortalURL
+	}
+
+	protocol := strings.ToLower(cfg.Protocol)
+	if protocol != "http" {
+		protocol = "https"
+	}
+
+	apiTimeout := cfg.APITimeout
+	if apiTimeout <= 0 {
+		apiTimeout = 60 * time.Second
+	}
+
 	return &SRADownloader{
-		outputDir:   cfg.OutputDir,
-		tempDir:     cfg.TempDir,
-		fasterqDump: fasterqDump,
-		prefetch:    prefetch,
-		threads:     threads,
-		logger:      logger,
+		outputDir:    cfg.OutputDir,
+		tempDir:      cfg.TempDir,
+		fasterqDump:  fasterqDump,
+		prefetch:     prefetch,
+		threads:      threads,
+		enaPortalURL: enaPortalURL,
+		enaHost:      strings.TrimRight(cfg.ENAHost, "/"),
+		protocol:     protocol,
+		apiTimeout:   apiTimeout,
+		skipToolkit:  cfg.SkipSRAToolkit,
+		logger:       logger,
 	}
 }
 
@@ -294,7 +333,9 @@ func (d *SRADownloader) SmartDownloadWithProgress(ctx context.Context, accession
 	)
 
 	// Check if fasterq-dump is available
-	if d.isSRAToolkitAvailable() {
+	if d.skipToolkit {
+		d.logger.Info("SRA Toolkit disabled, using ENA direct download")
+	} else if d.isSRAToolkitAvailable() {
 		d.logger.Info("SRA Toolkit available, using fasterq-dump")
 		result, err := d.Download(ctx, accession)
 		if err == nil {
@@ -318,6 +359,39 @@ func (d *SRADownloader) isSRAToolkitAvailable() bool {
 	return err == nil
 }
 
+// enaFileReportURL builds the Portal API filereport query for a run accession.
+func (d *SRADownloader) enaFileReportURL(accession string) string {
+	query := url.Values{}
+	query.Set("accession", accession)
+	query.Set("result", "read_run")
+	query.Set("fields", enaFileReportFields)
+	query.Set("format", "json")
+	return d.enaPortalURL + "/filereport?" + query.Encode()
+}
+
+// fastqURL converts a fastq_ftp entry (e.g. ftp.sra.ebi.ac.uk/vol1/fastq/...)
+// to a download URL, rewriting the host when a mirror is configured.
+func (d *SRADownloader) fastqURL(ftpURL string) string {
+	path := ftpURL
+	if i := strings.Index(path, "://"); i >= 0 {
+		path = path[i+3:]
+	}
+
+	if d.enaHost != "" {
+		if i := strings.Index(path, "/"); i >= 0 {
+			path = path[i:]
+		} else {
+			path = "/" + path
+		}
+		if strings.Contains(d.enaHost, "://") {
+			return d.enaHost + path
+		}
+		return d.protocol + "://" + d.enaHost + path
+	}
+
+	return d.protocol + "://" + path
+}
+
 // ENAFileInfo contains information about a FASTQ file from ENA.
 type ENAFileInfo struct {
 	RunAccession string `json:"run_accession"`
@@ -350,10 +424,7 @@ func (d *SRADownloader) DownloadFromENA(ctx context.Context, accession string) (
 	}
 
 	// Get file URLs from ENA API
-	enaAPIURL := fmt.Sprintf(
-		"https://www.ebi.ac.uk/ena/portal/api/filereport?accession=%s&result=read_run&fields=run_accession,fastq_ftp,fastq_md5,fastq_bytes&format=json",
-		accession,
-	)
+	enaAPIURL := d.enaFileReportURL(accession)
 
 	d.logger.Debug("querying ENA API", zap.String("url", enaAPIURL))
 
@@ -364,7 +435,7 @@ func (d *SRADownloader) DownloadFromENA(ctx context.Context, accession string) (
 		return result, err
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := &http.Client{Timeout: d.apiTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		result.Status = "failed"
@@ -406,8 +477,7 @@ func (d *SRADownloader) DownloadFromENA(ctx context.Context, accession string) (
 				continue
 			}
 
-			// Convert FTP URL to HTTP
-			httpURL := "https://" + strings.TrimPrefix(ftpURL, "ftp://")
+			httpURL := d.fastqURL(ftpURL)
 			
 			filename := filepath.Base(ftpURL)
 			outputFile := filepath.Join(outputPath, filename)
@@ -485,10 +555,7 @@ func (d *SRADownloader) DownloadFromENAWithProgress(ctx context.Context, accessi
 	}
 
 	// Get file URLs from ENA API
-	enaAPIURL := fmt.Sprintf(
-		"https://www.ebi.ac.uk/ena/portal/api/filereport?accession=%s&result=read_run&fields=run_accession,fastq_ftp,fastq_md5,fastq_bytes&format=json",
-		accession,
-	)
+	enaAPIURL := d.enaFileReportURL(accession)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", enaAPIURL, nil)
 	if err != nil {
@@ -497,7 +564,7 @@ func (d *SRADownloader) DownloadFromENAWithProgress(ctx context.Context, accessi
 		return result, err
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := &http.Client{Timeout: d.apiTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		result.Status = "failed"
@@ -572,8 +639,7 @@ func (d *SRADownloader) DownloadFromENAWithProgress(ctx context.Context, accessi
 				fileSize, _ = strconv.ParseInt(byteSizes[i], 10, 64)
 			}
 
-			// Convert FTP URL to HTTP
-			httpURL := "https://" + strings.TrimPrefix(ftpURL, "ftp://")
+			httpURL := d.fastqURL(ftpURL)
 			filename := filepath.Base(ftpURL)
 			outputFile := filepath.Join(outputPath, filename)
 
@@ -0,0 +1,117 @@
+package download
+
+import (
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestFastqURL(t *testing.T) {
+	const ftpPath = "ftp.sra.ebi.ac.uk/vol1/fastq/SRR123/001/SRR1230001/SRR1230001_1.fastq.gz"
+
+	tests := []struct {
+		name     string
+		enaHost  string
+		protocol string
+		ftpURL   string
+		want     string
+	}{
+		{
+			name:   "public host",
+			ftpURL: ftpPath,
+			want:   "https://" + ftpPath,
+		},
+		{
+			name:     "http protocol",
+			protocol: "HTTP",
+			ftpURL:   ftpPath,
+			want:     "http://" + ftpPath,
+		},
+		{
+			name:     "unknown protocol falls back to https",
+			protocol: "ftp",
+			ftpURL:   ftpPath,
+			want:     "https://" + ftpPath,
+		},
+		{
+			name:   "scheme in entry is replaced",
+			ftpURL: "ftp://" + ftpPath,
+			want:   "https://" + ftpPath,
+		},
+		{
+			name:    "mirror host",
+			enaHost: "mirror.local",
+			ftpURL:  ftpPath,
+			want:    "https://mirror.local/vol1/fastq/SRR123/001/SRR1230001/SRR1230001_1.fastq.gz",
+		},
+		{
+			name:     "mirror host with path prefix and trailing slash",
+			enaHost:  "mirror.local/ena/",
+			protocol: "http",
+			ftpURL:   "ftp://" + ftpPath,
+			want:     "http://mirror.local/ena/vol1/fastq/SRR123/001/SRR1230001/SRR1230001_1.fastq.gz",
+		},
+		{
+			name:     "mirror with scheme keeps it",
+			enaHost:  "https://mirror.local:8443/ena",
+			protocol: "http",
+			ftpURL:   ftpPath,
+			want:     "https://mirror.local:8443/ena/vol1/fastq/SRR123/001/SRR1230001/SRR1230001_1.fastq.gz",
+		},
+		{
+			name:    "mirror with entry lacking a path",
+			enaHost: "mirror.local",
+			ftpURL:  "SRR1230001_1.fastq.gz",
+			want:    "https://mirror.local/SRR1230001_1.fastq.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewSRADownloader(Config{ENAHost: tt.enaHost, Protocol: tt.protocol}, zap.NewNop())
+			if got := d.fastqURL(tt.ftpURL); got != tt.want {
+				t.Errorf("fastqURL(%q) = %q, want %q", tt.ftpURL, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestENAFileReportURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		portalURL string
+		wantBase  string
+	}{
+		{"default portal", "", DefaultENAPortalURL + "/filereport"},
+		{"mirror portal", "http://mirror.local/ena/portal/api", "http://mirror.local/ena/portal/api/filereport"},
+		{"trailing slash", "http://mirror.local/ena/portal/api/", "http://mirror.local/ena/portal/api/filereport"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewSRADownloader(Config{ENAPortalURL: tt.portalURL}, zap.NewNop())
+			parsed, err := url.Parse(d.enaFileReportURL("SRR1230001"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query := parsed.Query()
+			parsed.RawQuery = ""
+			if parsed.String() != tt.wantBase {
+				t.Errorf("base = %q, want %q", parsed.String(), tt.wantBase)
+			}
+			want := map[string]string{
+				"accession": "SRR1230001",
+				"result":    "read_run",
+				"fields":    enaFileReportFields,
+				"format":    "json",
+			}
+			for key, value := range want {
+				if got := query.Get(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+		})
+	}
+}
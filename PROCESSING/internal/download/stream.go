@@ -0,0 +1,302 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// streamDrainTimeout bounds how long Close waits for downloads after a
+// consumer exited cleanly. Data still arriving then has no reader, and a
+// stalled connection would otherwise hold the download open.
+var streamDrainTimeout = 30 * time.Second
+
+// pipeReleaseInterval is how often Close releases writers blocked opening a
+// pipe.
+const pipeReleaseInterval = 100 * time.Millisecond
+
+// Stream feeds remote ENA FASTQ files into named pipes so a consumer such as
+// Trimmomatic can read them while they download, without the raw reads ever
+// being written to disk.
+type Stream struct {
+	Accession  string   `json:"accession"`
+	OutputDir  string   `json:"output_dir"`
+	URLs       []string `json:"urls"`
+	Pipes      []string `json:"-"`
+	TotalBytes int64    `json:"total_bytes"`
+
+	dir        string
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+	err        error
+	downloaded atomic.Int64
+	logger     *zap.Logger
+}
+
+// BytesDownloaded returns the number of bytes streamed so far.
+func (s *Stream) BytesDownloaded() int64 {
+	return s.downloaded.Load()
+}
+
+// OpenENAStream looks up the FASTQ files of a run in ENA and starts streaming
+// them into named pipes under the temp directory. Paired-end runs yield two
+// pipes (read 1, read 2); single-end runs yield one. The caller must open the
+// pipes for reading and then call Close. Processed reads belong under
+// OutputDir, which is created but receives no raw FASTQ.
+func (d *SRADownloader) OpenENAStream(ctx context.Context, accession string, progressFn func(downloaded, total int64)) (*Stream, error) {
+	files, err := d.fetchENAFileReport(ctx, accession)
+	if err != nil {
+		return nil, err
+	}
+
+	urls, sizes := selectReadFiles(files)
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no files found for %s", accession)
+	}
+
+	outputPath := filepath.Join(d.outputDir, accession)
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	dir, err := os.MkdirTemp(d.tempDir, "stream_"+accession+"_")
+	if err != nil {
+		return nil, fmt.Errorf("creating pipe directory: %w", err)
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &Stream{
+		Accession: accession,
+		OutputDir: outputPath,
+		dir:       dir,
+		cancel:    cancel,
+		logger:    d.logger,
+	}
+
+	for i, ftpURL := range urls {
+		// Keep the original name so consumers detect gzip from the extension
+		pipe := filepath.Join(dir, filepath.Base(ftpURL))
+		if err := syscall.Mkfifo(pipe, 0600); err != nil {
+			cancel()
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("creating named pipe: %w", err)
+		}
+		stream.URLs = append(stream.URLs, d.fastqURL(ftpURL))
+		stream.Pipes = append(stream.Pipes, pipe)
+		stream.TotalBytes += sizes[i]
+	}
+
+	d.logger.Info("streaming ENA reads",
+		zap.String("accession", accession),
+		zap.Strings("urls", stream.URLs),
+		zap.Int64("bytes", stream.TotalBytes),
+	)
+
+	for i := range stream.URLs {
+		stream.wg.Add(1)
+		go func(url, pipe string) {
+			defer stream.wg.Done()
+			if err := stream.pump(streamCtx, url, pipe, progressFn); err != nil {
+				stream.fail(fmt.Errorf("streaming %s: %w", filepath.Base(pipe), err))
+			}
+		}(stream.URLs[i], stream.Pipes[i])
+	}
+
+	return stream, nil
+}
+
+// pump copies one remote file into its pipe. Opening the pipe blocks until
+// the consumer opens it for reading.
+func (s *Stream) pump(ctx context.Context, url, pipe string, progressFn func(downloaded, total int64)) error {
+	out, err := os.OpenFile(pipe, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 60 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	buf := make([]byte, 1024*1024)
+	lastReport := time.Now()
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := out.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
+			downloaded := s.downloaded.Add(int64(n))
+			if progressFn != nil && time.Since(lastReport) > 2*time.Second {
+				progressFn(downloaded, s.TotalBytes)
+				lastReport = time.Now()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Stream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Close waits for the downloads to finish, removes the pipes and returns the
+// first download error. consumerErr is the error of the program that read the
+// pipes: when it failed, the downloads are cancelled instead of waited for.
+// Downloads still running streamDrainTimeout after a clean exit are cancelled
+// and reported as failed.
+func (s *Stream) Close(consumerErr error) error {
+	if consumerErr != nil {
+		s.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	// The consumer has exited, so writers still blocked opening a pipe it never
+	// read would wait forever. Opening and closing a reader releases them; their
+	// next write fails with a broken pipe. A writer may reach its open after a
+	// release, so releases repeat until every download has returned.
+	release := time.NewTicker(pipeReleaseInterval)
+	defer release.Stop()
+	drain := time.After(streamDrainTimeout)
+	for waiting := true; waiting; {
+		s.releasePipes()
+		select {
+		case <-done:
+			waiting = false
+		case <-release.C:
+		case <-drain:
+			s.fail(fmt.Errorf("download still running %s after the consumer exited", streamDrainTimeout))
+			s.cancel()
+		}
+	}
+
+	s.cancel()
+	os.RemoveAll(s.dir)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		s.logger.Warn("ENA stream failed", zap.String("accession", s.Accession), zap.Error(s.err))
+	}
+	return s.err
+}
+
+// releasePipes opens and closes each pipe for reading without blocking.
+func (s *Stream) releasePipes() {
+	for _, pipe := range s.Pipes {
+		if f, err := os.OpenFile(pipe, os.O_RDONLY|syscall.O_NONBLOCK, 0); err == nil {
+			f.Close()
+		}
+	}
+}
+
+// fetchENAFileReport queries the ENA Portal API for the FASTQ files of a run.
+func (d *SRADownloader) fetchENAFileReport(ctx context.Context, accession string) ([]ENAFileInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", d.enaFileReportURL(accession), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: d.apiTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ENA API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ENA API error: %d", resp.StatusCode)
+	}
+
+	var files []ENAFileInfo
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, fmt.Errorf("failed to parse ENA response: %w", err)
+	}
+	return files, nil
+}
+
+// selectReadFiles picks the read files of a run: _1/_2 for paired-end runs
+// (ignoring the orphan-read file ENA lists alongside them), otherwise the
+// first file.
+func selectReadFiles(files []ENAFileInfo) (urls []string, sizes []int64) {
+	var all []string
+	var allSizes []int64
+	for _, file := range files {
+		if file.FastqFTP == "" {
+			continue
+		}
+		byteSizes := strings.Split(file.FastqBytes, ";")
+		for i, ftpURL := range strings.Split(file.FastqFTP, ";") {
+			if ftpURL == "" {
+				continue
+			}
+			var size int64
+			if i < len(byteSizes) {
+				size, _ = strconv.ParseInt(byteSizes[i], 10, 64)
+			}
+			all = append(all, ftpURL)
+			allSizes = append(allSizes, size)
+		}
+	}
+
+	read1, read2 := -1, -1
+	for i, u := range all {
+		name := filepath.Base(u)
+		switch {
+		case strings.Contains(name, "_1.f"):
+			read1 = i
+		case strings.Contains(name, "_2.f"):
+			read2 = i
+		}
+	}
+	if read1 >= 0 && read2 >= 0 {
+		return []string{all[read1], all[read2]}, []int64{allSizes[read1], allSizes[read2]}
+	}
+	if len(all) > 0 {
+		return all[:1], allSizes[:1]
+	}
+	return nil, nil
+}
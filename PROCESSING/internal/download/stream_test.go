@@ -0,0 +1,240 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// enaServer serves a Portal API filereport for SRR1 and its read files.
+// Each file handler receives the response writer and request.
+func enaServer(t *testing.T, files map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+
+	var names, sizes []string
+	for name, handler := range files {
+		names = append(names, "ftp.sra.ebi.ac.uk/vol1/fastq/SRR1/"+name)
+		sizes = append(sizes, "0")
+		mux.HandleFunc("/vol1/fastq/SRR1/"+name, handler)
+	}
+	mux.HandleFunc("/filereport", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]ENAFileInfo{{
+			RunAccession: "SRR1",
+			FastqFTP:     strings.Join(names, ";"),
+			FastqBytes:   strings.Join(sizes, ";"),
+		}})
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func serveBytes(data []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}
+}
+
+func newStreamDownloader(t *testing.T, srv *httptest.Server) *SRADownloader {
+	t.Helper()
+	return NewSRADownloader(Config{
+		OutputDir:    t.TempDir(),
+		TempDir:      t.TempDir(),
+		ENAPortalURL: srv.URL,
+		ENAHost:      srv.URL,
+	}, zap.NewNop())
+}
+
+// closeWithin calls Close and fails the test if it does not return in time.
+func closeWithin(t *testing.T, stream *Stream, consumerErr error, timeout time.Duration) error {
+	t.Helper()
+	result := make(chan error, 1)
+	go func() { result <- stream.Close(consumerErr) }()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		t.Fatalf("Close did not return within %s", timeout)
+		return nil
+	}
+}
+
+func TestStreamLifecycle(t *testing.T) {
+	read1 := bytes.Repeat([]byte("@r1\nACGT\n+\nIIII\n"), 50000) // Larger than a pipe buffer
+	read2 := bytes.Repeat([]byte("@r2\nTGCA\n+\nIIII\n"), 50000)
+	srv := enaServer(t, map[string]http.HandlerFunc{
+		"SRR1_1.fastq.gz": serveBytes(read1),
+		"SRR1_2.fastq.gz": serveBytes(read2),
+		"SRR1.fastq.gz":   serveBytes([]byte("orphans")),
+	})
+	d := newStreamDownloader(t, srv)
+
+	stream, err := d.OpenENAStream(context.Background(), "SRR1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.Pipes) != 2 {
+		t.Fatalf("pipes = %v, want read 1 and read 2", stream.Pipes)
+	}
+	for i, want := range []string{"SRR1_1.fastq.gz", "SRR1_2.fastq.gz"} {
+		info, err := os.Stat(stream.Pipes[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			t.Errorf("%s is not a named pipe", stream.Pipes[i])
+		}
+		if filepath.Base(stream.Pipes[i]) != want {
+			t.Errorf("pipe %d = %s, want %s", i, filepath.Base(stream.Pipes[i]), want)
+		}
+	}
+
+	// Read both pipes concurrently, as a paired-end consumer does
+	got := make([][]byte, 2)
+	var wg sync.WaitGroup
+	for i, pipe := range stream.Pipes {
+		wg.Add(1)
+		go func(i int, pipe string) {
+			defer wg.Done()
+			got[i], _ = os.ReadFile(pipe)
+		}(i, pipe)
+	}
+	wg.Wait()
+
+	if err := closeWithin(t, stream, nil, 5*time.Second); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !bytes.Equal(got[0], read1) || !bytes.Equal(got[1], read2) {
+		t.Error("pipes did not deliver the downloaded files")
+	}
+	if n := stream.BytesDownloaded(); n != int64(len(read1)+len(read2)) {
+		t.Errorf("downloaded %d bytes, want %d", n, len(read1)+len(read2))
+	}
+	if _, err := os.Stat(filepath.Dir(stream.Pipes[0])); !os.IsNotExist(err) {
+		t.Error("pipe directory not removed")
+	}
+	if info, err := os.Stat(stream.OutputDir); err != nil || !info.IsDir() {
+		t.Errorf("output directory missing: %v", err)
+	}
+}
+
+func TestStreamCloseReleasesUnopenedPipes(t *testing.T) {
+	srv := enaServer(t, map[string]http.HandlerFunc{
+		"SRR1_1.fastq.gz": serveBytes([]byte("@r1\nACGT\n+\nIIII\n")),
+		"SRR1_2.fastq.gz": serveBytes([]byte("@r2\nTGCA\n+\nIIII\n")),
+	})
+	d := newStreamDownloader(t, srv)
+
+	stream, err := d.OpenENAStream(context.Background(), "SRR1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The consumer failed before opening the pipes, leaving both writers
+	// blocked in open
+	err = closeWithin(t, stream, errors.New("trimmer failed to start"), 5*time.Second)
+	if err == nil {
+		t.Error("expected the aborted downloads to be reported")
+	}
+	if _, err := os.Stat(filepath.Dir(stream.Pipes[0])); !os.IsNotExist(err) {
+		t.Error("pipe directory not removed")
+	}
+}
+
+func TestStreamConsumerExitsEarly(t *testing.T) {
+	chunk := bytes.Repeat([]byte("@r\nACGT\n+\nIIII\n"), 64) // 1 KiB
+	// A slow mirror sends a chunk and stalls until the client goes away
+	stalled := func(w http.ResponseWriter, r *http.Request) {
+		w.Write(chunk)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}
+
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		consumerErr error
+		drain       time.Duration
+		wantErr     string
+	}{
+		{
+			// Writes to a pipe without a reader fail at once
+			name:    "download blocked writing",
+			handler: serveBytes(bytes.Repeat(chunk, 512)),
+			drain:   time.Hour,
+			wantErr: "broken pipe",
+		},
+		{
+			name:        "consumer failed while download stalls",
+			handler:     stalled,
+			consumerErr: errors.New("trimmer crashed"),
+			drain:       time.Hour,
+			wantErr:     "context canceled",
+		},
+		{
+			name:    "consumer exited cleanly while download stalls",
+			handler: stalled,
+			drain:   100 * time.Millisecond,
+			wantErr: "still running",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := enaServer(t, map[string]http.HandlerFunc{"SRR1.fastq.gz": tt.handler})
+			d := newStreamDownloader(t, srv)
+
+			drain := streamDrainTimeout
+			streamDrainTimeout = tt.drain
+			defer func() { streamDrainTimeout = drain }()
+
+			stream, err := d.OpenENAStream(context.Background(), "SRR1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Read the first chunk and exit without draining the pipe
+			pipe, err := os.Open(stream.Pipes[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.ReadFull(pipe, make([]byte, len(chunk))); err != nil {
+				t.Fatal(err)
+			}
+			pipe.Close()
+
+			err = closeWithin(t, stream, tt.consumerErr, 5*time.Second)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Dir(stream.Pipes[0])); !os.IsNotExist(err) {
+				t.Error("pipe directory not removed")
+			}
+		})
+	}
+}
+
+func TestStreamNoFiles(t *testing.T) {
+	srv := enaServer(t, nil)
+	d := newStreamDownloader(t, srv)
+
+	_, err := d.OpenENAStream(context.Background(), "SRR1", nil)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("no files found for %s", "SRR1")) {
+		t.Errorf("error = %v, want no files error", err)
+	}
+}
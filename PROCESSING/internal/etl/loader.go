@@ -2,7 +2,6 @@
 package etl
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,20 +9,23 @@ import (
 	"time"
 
 	"github.com/guidiju-50/pandora/PROCESSING/internal/config"
+	"github.com/guidiju-50/pandora/SHARED/discovery"
 	"go.uber.org/zap"
 )
 
 // Loader handles loading data to the CONTROL module's Data Warehouse.
 type Loader struct {
-	config config.ControlAPIConfig
-	client *http.Client
-	logger *zap.Logger
+	config   config.ControlAPIConfig
+	registry *discovery.Registry
+	client   *http.Client
+	logger   *zap.Logger
 }
 
 // NewLoader creates a new Loader.
-func NewLoader(cfg config.ControlAPIConfig, logger *zap.Logger) *Loader {
+func NewLoader(cfg config.ControlAPIConfig, registry *discovery.Registry, logger *zap.Logger) *Loader {
 	return &Loader{
-		config: cfg,
+		config:   cfg,
+		registry: registry,
 		client: &http.Client{
 			Timeout: cfg.Timeout,
 		},
@@ -44,18 +46,13 @@ func (l *Loader) LoadBatch(ctx context.Context, records []*TransformedRecord) er
 	}
 
 	// Send to CONTROL API
-	url := fmt.Sprintf("%s/api/v1/warehouse/records", l.config.URL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
-	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
 	if l.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+l.config.APIKey)
+		header.Set("Authorization", "Bearer "+l.config.APIKey)
 	}
 
-	resp, err := l.client.Do(req)
+	resp, err := l.registry.Do(ctx, l.client, discovery.ServiceControl, http.MethodPost, "/api/v1/warehouse/records", data, header)
 	if err != nil {
 		return fmt.Errorf("sending request: %w", err)
 	}
@@ -132,13 +129,7 @@ func (l *Loader) preparePayload(records []*TransformedRecord) *RecordPayload {
 
 // HealthCheck checks if the CONTROL API is available.
 func (l *Loader) HealthCheck(ctx context.Context) error {
-	url := fmt.Sprintf("%s/health", l.config.URL)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return err
-	}
-
-	resp, err := l.client.Do(req)
+	resp, err := l.registry.Do(ctx, l.client, discovery.ServiceControl, http.MethodGet, "/health", nil, nil)
 	if err != nil {
 		return err
 	}
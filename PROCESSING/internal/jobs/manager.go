@@ -43,19 +43,33 @@ type ProgressUpdate struct {
 	Status   Status `json:"status"`
 }
 
+// runnerRetention is how long a failed or cancelled job can be retried
+// before its job function is released.
+const runnerRetention = 24 * time.Hour
+
+// RunFunc is the work performed by an async job.
+type RunFunc func(ctx context.Context, updateProgress func(int, string)) (map[string]interface{}, error)
+
 // Manager handles async job execution and tracking.
 type Manager struct {
+	ctx         context.Context // Lifecycle of the manager; cancelling it stops all runs
 	jobs        map[string]*Job
 	cancelFuncs map[string]context.CancelFunc
+	runners     map[string]RunFunc
+	attempts    map[string]int // Incremented on every run; stale runs must not touch the job
 	subscribers map[string][]chan ProgressUpdate
 	mu          sync.RWMutex
 }
 
-// NewManager creates a new job manager.
-func NewManager() *Manager {
+// NewManager creates a new job manager. Runs, including retries, are
+// cancelled when ctx is done.
+func NewManager(ctx context.Context) *Manager {
 	return &Manager{
+		ctx:         ctx,
 		jobs:        make(map[string]*Job),
 		cancelFuncs: make(map[string]context.CancelFunc),
+		runners:     make(map[string]RunFunc),
+		attempts:    make(map[string]int),
 		subscribers: make(map[string][]chan ProgressUpdate),
 	}
 }
@@ -104,7 +118,10 @@ func (m *Manager) GetAllJobs() []*Job {
 func (m *Manager) StartJob(id string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.startJob(id)
+}
 
+func (m *Manager) startJob(id string) {
 	if job, ok := m.jobs[id]; ok {
 		now := time.Now()
 		job.Status = StatusRunning
@@ -123,7 +140,10 @@ func (m *Manager) StartJob(id string) {
 func (m *Manager) UpdateProgress(id string, progress int, message string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.updateProgress(id, progress, message)
+}
 
+func (m *Manager) updateProgress(id string, progress int, message string) {
 	if job, ok := m.jobs[id]; ok {
 		job.Progress = progress
 		job.Message = message
@@ -140,7 +160,10 @@ func (m *Manager) UpdateProgress(id string, progress int, message string) {
 func (m *Manager) CompleteJob(id string, output map[string]interface{}) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.completeJob(id, output)
+}
 
+func (m *Manager) completeJob(id string, output map[string]interface{}) {
 	if job, ok := m.jobs[id]; ok {
 		now := time.Now()
 		job.Status = StatusCompleted
@@ -155,6 +178,8 @@ func (m *Manager) CompleteJob(id string, output map[string]interface{}) {
 			Status:   StatusCompleted,
 		})
 		m.closeSubscribers(id)
+		// Completed jobs cannot be retried, so their job function is no longer needed
+		delete(m.runners, id)
 	}
 }
 
@@ -162,7 +187,10 @@ func (m *Manager) CompleteJob(id string, output map[string]interface{}) {
 func (m *Manager) FailJob(id string, err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.failJob(id, err)
+}
 
+func (m *Manager) failJob(id string, err error) {
 	if job, ok := m.jobs[id]; ok {
 		now := time.Now()
 		job.Status = StatusFailed
@@ -281,56 +309,224 @@ func (m *Manager) closeSubscribers(id string) {
 }
 
 // RunAsync executes a job function asynchronously with cancellation support.
-func (m *Manager) RunAsync(ctx context.Context, jobID string, fn func(ctx context.Context, updateProgress func(int, string)) (map[string]interface{}, error)) {
-	// Create cancellable context
+// Each call starts a new attempt; a run left over from an earlier attempt
+// (e.g. a cancelled run still unwinding after a retry) never updates the job.
+func (m *Manager) RunAsync(ctx context.Context, jobID string, fn RunFunc) {
+	// Create cancellable context, also cancelled when the manager stops
 	jobCtx, cancel := context.WithCancel(ctx)
-	
-	// Store cancel function
+	stop := context.AfterFunc(m.ctx, cancel)
+
+	// Store cancel function and keep the job function for retries
 	m.mu.Lock()
+	m.pruneRunners()
+	m.attempts[jobID]++
+	attempt := m.attempts[jobID]
 	m.cancelFuncs[jobID] = cancel
+	m.runners[jobID] = fn
 	m.mu.Unlock()
 
 	go func() {
-		defer func() {
-			m.mu.Lock()
+		defer stop()
+		defer cancel()
+		defer m.ifCurrent(jobID, attempt, func(*Job) {
 			delete(m.cancelFuncs, jobID)
-			m.mu.Unlock()
-		}()
+		})
 
-		m.StartJob(jobID)
+		m.ifCurrent(jobID, attempt, func(*Job) {
+			m.startJob(jobID)
+		})
 
 		updateProgress := func(progress int, message string) {
-			// Check if cancelled before updating
-			if m.IsCancelled(jobID) {
-				return
-			}
-			m.UpdateProgress(jobID, progress, message)
+			m.ifCurrent(jobID, attempt, func(job *Job) {
+				// Cancelled jobs keep their last progress
+				if job.Status != StatusCancelled {
+					m.updateProgress(jobID, progress, message)
+				}
+			})
 		}
 
 		result, err := fn(jobCtx, updateProgress)
-		
-		// Check if job was cancelled
-		if m.IsCancelled(jobID) {
-			return // Already marked as cancelled
-		}
-		
-		if err != nil {
-			// Check if error is due to context cancellation
-			if jobCtx.Err() == context.Canceled {
-				m.mu.Lock()
-				if job, ok := m.jobs[jobID]; ok && job.Status != StatusCancelled {
+
+		m.ifCurrent(jobID, attempt, func(job *Job) {
+			// Already marked as cancelled
+			if job.Status == StatusCancelled {
+				return
+			}
+
+			if err != nil {
+				// Check if error is due to context cancellation
+				if jobCtx.Err() == context.Canceled {
 					now := time.Now()
 					job.Status = StatusCancelled
 					job.Message = "Job cancelled"
 					job.CompletedAt = &now
+					return
 				}
-				m.mu.Unlock()
+				m.failJob(jobID, err)
 				return
 			}
-			m.FailJob(jobID, err)
-			return
-		}
 
-		m.CompleteJob(jobID, result)
+			m.completeJob(jobID, result)
+		})
 	}()
 }
+
+// ifCurrent runs fn under the lock if the job exists and attempt is its
+// latest run.
+func (m *Manager) ifCurrent(jobID string, attempt int, fn func(job *Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok || m.attempts[jobID] != attempt {
+		return
+	}
+	fn(job)
+}
+
+// pruneRunners releases the job functions of jobs that failed or were
+// cancelled more than runnerRetention ago; those jobs can no longer be
+// retried. The caller must hold m.mu.
+func (m *Manager) pruneRunners() {
+	for id := range m.runners {
+		job, ok := m.jobs[id]
+		if !ok || (job.CompletedAt != nil && time.Since(*job.CompletedAt) > runnerRetention) {
+			delete(m.runners, id)
+		}
+	}
+}
+
+// Filter selects jobs for bulk operations. Empty fields match every job.
+type Filter struct {
+	Type          string
+	Statuses      []Status
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// IsEmpty reports whether the filter has no criteria and would match every job.
+func (f Filter) IsEmpty() bool {
+	return f.Type == "" && len(f.Statuses) == 0 && f.CreatedAfter == nil && f.CreatedBefore == nil
+}
+
+// matches reports whether a job satisfies the filter.
+func (f Filter) matches(job *Job) bool {
+	if f.Type != "" && job.Type != f.Type {
+		return false
+	}
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, s := range f.Statuses {
+			if job.Status == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.CreatedAfter != nil && job.CreatedAt.Before(*f.CreatedAfter) {
+		return false
+	}
+	if f.CreatedBefore != nil && !job.CreatedAt.Before(*f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// matchingIDs returns the IDs of jobs matching the filter. An empty filter
+// matches nothing, so bulk operations never affect every job by accident.
+func (m *Manager) matchingIDs(filter Filter) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0)
+	if filter.IsEmpty() {
+		return ids
+	}
+	for id, job := range m.jobs {
+		if filter.matches(job) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CancelJobs cancels all pending or running jobs matching the filter and
+// returns the IDs of the cancelled jobs.
+func (m *Manager) CancelJobs(filter Filter) []string {
+	cancelled := make([]string, 0)
+	for _, id := range m.matchingIDs(filter) {
+		if m.CancelJob(id) {
+			cancelled = append(cancelled, id)
+		}
+	}
+	return cancelled
+}
+
+// RetryJob re-runs a failed or cancelled job with its original job function.
+// Jobs that finished more than runnerRetention ago cannot be retried.
+func (m *Manager) RetryJob(id string) bool {
+	m.mu.Lock()
+	m.pruneRunners()
+
+	job, ok := m.jobs[id]
+	fn, hasRunner := m.runners[id]
+	if !ok || !hasRunner || (job.Status != StatusFailed && job.Status != StatusCancelled) {
+		m.mu.Unlock()
+		return false
+	}
+
+	job.Status = StatusPending
+	job.Progress = 0
+	job.Message = "Job queued for retry"
+	job.Output = make(map[string]interface{})
+	job.Error = ""
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	m.mu.Unlock()
+
+	m.RunAsync(m.ctx, id, fn)
+	return true
+}
+
+// RetryJobs re-runs all failed or cancelled jobs matching the filter and
+// returns the IDs of the retried jobs.
+func (m *Manager) RetryJobs(filter Filter) []string {
+	retried := make([]string, 0)
+	for _, id := range m.matchingIDs(filter) {
+		if m.RetryJob(id) {
+			retried = append(retried, id)
+		}
+	}
+	return retried
+}
+
+// DeleteJobs removes all completed, failed or cancelled jobs matching the
+// filter and returns the IDs of the deleted jobs.
+func (m *Manager) DeleteJobs(filter Filter) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := make([]string, 0)
+	if filter.IsEmpty() {
+		return deleted
+	}
+	for id, job := range m.jobs {
+		if !filter.matches(job) {
+			continue
+		}
+		// Never delete jobs that are still queued or executing
+		if job.Status == StatusPending || job.Status == StatusRunning {
+			continue
+		}
+
+		m.closeSubscribers(id)
+		delete(m.runners, id)
+		delete(m.attempts, id)
+		delete(m.jobs, id)
+		deleted = append(deleted, id)
+	}
+	return deleted
+}
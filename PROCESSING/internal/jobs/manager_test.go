@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitStatus waits until a job reaches the given status.
+func waitStatus(t *testing.T, m *Manager, id string, status Status) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.RLock()
+		current := m.jobs[id].Status
+		m.mu.RUnlock()
+		if current == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach status %s", id, status)
+}
+
+func TestRetryJobStopsWithManager(t *testing.T) {
+	ctx, stop := context.WithCancel(context.Background())
+	m := NewManager(ctx)
+
+	id := m.CreateJob("test", nil)
+	attempts := 0
+	m.RunAsync(context.Background(), id, func(ctx context.Context, _ func(int, string)) (map[string]interface{}, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("first attempt fails")
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	waitStatus(t, m, id, StatusFailed)
+
+	if !m.RetryJob(id) {
+		t.Fatal("failed job was not retried")
+	}
+	waitStatus(t, m, id, StatusRunning)
+
+	stop()
+	waitStatus(t, m, id, StatusCancelled)
+}
+
+func TestRunnerRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		age       time.Duration
+		retryable bool
+	}{
+		{"recently failed", time.Minute, true},
+		{"failed beyond retention", runnerRetention + time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(context.Background())
+			id := m.CreateJob("test", nil)
+			m.RunAsync(context.Background(), id, func(context.Context, func(int, string)) (map[string]interface{}, error) {
+				return nil, errors.New("failed")
+			})
+			waitStatus(t, m, id, StatusFailed)
+
+			m.mu.Lock()
+			completed := time.Now().Add(-tt.age)
+			m.jobs[id].CompletedAt = &completed
+			m.mu.Unlock()
+
+			if got := m.RetryJob(id); got != tt.retryable {
+				t.Errorf("RetryJob = %v, want %v", got, tt.retryable)
+			}
+			m.mu.RLock()
+			_, kept := m.runners[id]
+			m.mu.RUnlock()
+			if kept != tt.retryable {
+				t.Errorf("runner kept = %v, want %v", kept, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestDeleteJobsReleasesRunner(t *testing.T) {
+	m := NewManager(context.Background())
+	id := m.CreateJob("test", nil)
+	m.RunAsync(context.Background(), id, func(context.Context, func(int, string)) (map[string]interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	waitStatus(t, m, id, StatusFailed)
+
+	if deleted := m.DeleteJobs(Filter{Type: "test"}); len(deleted) != 1 {
+		t.Fatalf("deleted %v, want %s", deleted, id)
+	}
+	if _, ok := m.runners[id]; ok {
+		t.Error("runner of deleted job kept")
+	}
+}
@@ -96,6 +96,28 @@ type TrimmingResult struct {
 	ProcessingTime float64  `json:"processing_time_seconds"`
 }
 
+// CropReport represents the result of fixed-length read cropping for a sample.
+type CropReport struct {
+	SampleID               string   `json:"sample_id,omitempty"`
+	CropLength             int      `json:"crop_length"`
+	InputReads             int64    `json:"input_reads"`
+	OutputReads            int64    `json:"output_reads"`
+	DroppedReads           int64    `json:"dropped_reads"`
+	InputBases             int64    `json:"input_bases"`
+	OutputBases            int64    `json:"output_bases"`
+	BasesRemoved           int64    `json:"bases_removed"`
+	BasesRemovedPercentage float64  `json:"bases_removed_percentage"`
+	OutputFiles            []string `json:"output_files"`
+	ProcessingTime         float64  `json:"processing_time_seconds"`
+}
+
+// HarmonizationReport represents read length harmonization across samples.
+type HarmonizationReport struct {
+	CropLength        int           `json:"crop_length"`
+	Samples           []*CropReport `json:"samples"`
+	TotalBasesRemoved int64         `json:"total_bases_removed"`
+}
+
 // QualityMetrics represents sequence quality metrics.
 type QualityMetrics struct {
 	TotalReads      int64   `json:"total_reads"`
@@ -0,0 +1,431 @@
+package trimming
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/guidiju-50/pandora/PROCESSING/internal/models"
+	"go.uber.org/zap"
+)
+
+// lengthSampleReads is the number of reads inspected to detect the sequenced read length.
+const lengthSampleReads = 100000
+
+// Cropper harmonizes read lengths by cropping reads to a fixed length after trimming.
+type Cropper struct {
+	logger *zap.Logger
+}
+
+// NewCropper creates a new Cropper.
+func NewCropper(logger *zap.Logger) *Cropper {
+	return &Cropper{
+		logger: logger,
+	}
+}
+
+// CropOptions holds options for a cropping run.
+type CropOptions struct {
+	SampleID    string
+	InputFile1  string // Forward reads (or single-end reads)
+	InputFile2  string // Reverse reads (empty for single-end)
+	OutputDir   string
+	Length      int  // Target read length
+	KeepShorter bool // Keep reads shorter than Length instead of dropping them
+}
+
+// fastqRecord is a single FASTQ entry.
+type fastqRecord struct {
+	header  string
+	seq     string
+	plus    string
+	quality string
+}
+
+// Crop crops every read of a sample to opts.Length bases. For paired-end input
+// both mates are processed in lockstep so that dropping a short read also drops
+// its mate and the output files stay in sync.
+func (c *Cropper) Crop(ctx context.Context, opts CropOptions) (*models.CropReport, error) {
+	startTime := time.Now()
+
+	if opts.InputFile1 == "" {
+		return nil, fmt.Errorf("input file 1 is required")
+	}
+	if opts.Length <= 0 {
+		return nil, fmt.Errorf("crop length must be positive")
+	}
+
+	isPaired := opts.InputFile2 != ""
+
+	c.logger.Info("cropping reads",
+		zap.String("sample", opts.SampleID),
+		zap.Int("length", opts.Length),
+		zap.Bool("paired", isPaired),
+	)
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating output directory: %w", err)
+	}
+
+	// Write into a scratch directory first so partially written files are never
+	// picked up by anything globbing the output directory.
+	partialDir := filepath.Join(opts.OutputDir, ".partial")
+	if err := os.MkdirAll(partialDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating scratch directory: %w", err)
+	}
+	defer os.RemoveAll(partialDir)
+
+	inputs := []string{opts.InputFile1}
+	if isPaired {
+		inputs = append(inputs, opts.InputFile2)
+	}
+
+	readers := make([]*fastqReader, 0, len(inputs))
+	writers := make([]*fastqWriter, 0, len(inputs))
+	defer func() {
+		for _, r := range readers {
+			r.Close()
+		}
+		for _, w := range writers {
+			w.Close()
+		}
+	}()
+
+	outputFiles := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		r, err := openFASTQ(input)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, r)
+
+		name := croppedFileName(input)
+		w, err := createFASTQ(filepath.Join(partialDir, name))
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, w)
+		outputFiles = append(outputFiles, filepath.Join(opts.OutputDir, name))
+	}
+
+	report := &models.CropReport{
+		SampleID:   opts.SampleID,
+		CropLength: opts.Length,
+	}
+
+	records := make([]*fastqRecord, len(readers))
+	for n := 0; ; n++ {
+		if n%10000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		ended := 0
+		for i, r := range readers {
+			rec, err := r.Next()
+			if err == io.EOF {
+				ended++
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", inputs[i], err)
+			}
+			records[i] = rec
+		}
+		if ended == len(readers) {
+			break
+		}
+		// Mates must end together, otherwise the pairing is broken
+		if ended > 0 {
+			return nil, fmt.Errorf("mate files have different read counts: %s", strings.Join(inputs, ", "))
+		}
+
+		report.InputReads++
+		short := false
+		for _, rec := range records {
+			report.InputBases += int64(len(rec.seq))
+			if len(rec.seq) < opts.Length {
+				short = true
+			}
+		}
+
+		if short && !opts.KeepShorter {
+			report.DroppedReads++
+			continue
+		}
+
+		for i, rec := range records {
+			if len(rec.seq) > opts.Length {
+				rec.seq = rec.seq[:opts.Length]
+				rec.quality = rec.quality[:opts.Length]
+			}
+			report.OutputBases += int64(len(rec.seq))
+			if err := writers[i].Write(rec); err != nil {
+				return nil, fmt.Errorf("writing cropped reads: %w", err)
+			}
+		}
+		report.OutputReads++
+	}
+
+	for _, w := range writers {
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("finalizing cropped reads: %w", err)
+		}
+	}
+	writers = nil
+
+	for _, output := range outputFiles {
+		if err := os.Rename(filepath.Join(partialDir, filepath.Base(output)), output); err != nil {
+			return nil, fmt.Errorf("moving cropped file: %w", err)
+		}
+	}
+
+	report.BasesRemoved = report.InputBases - report.OutputBases
+	report.OutputFiles = outputFiles
+	report.ProcessingTime = time.Since(startTime).Seconds()
+	if report.InputBases > 0 {
+		report.BasesRemovedPercentage = float64(report.BasesRemoved) / float64(report.InputBases) * 100
+	}
+
+	c.logger.Info("cropping completed",
+		zap.String("sample", opts.SampleID),
+		zap.Int64("input_reads", report.InputReads),
+		zap.Int64("dropped_reads", report.DroppedReads),
+		zap.Int64("bases_removed", report.BasesRemoved),
+	)
+
+	return report, nil
+}
+
+// HarmonizeSample identifies the trimmed reads of one sample to harmonize.
+type HarmonizeSample struct {
+	SampleID   string `json:"sample_id"`
+	InputFile1 string `json:"input_file_1"`
+	InputFile2 string `json:"input_file_2"`
+	OutputDir  string `json:"output_dir"`
+}
+
+// Harmonize crops all samples of an experiment to a common read length. When
+// length is zero, the shortest sequenced read length across the samples is used.
+func (c *Cropper) Harmonize(ctx context.Context, samples []HarmonizeSample, length int, keepShorter bool) (*models.HarmonizationReport, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("at least one sample is required")
+	}
+
+	if length <= 0 {
+		detected, err := c.commonReadLength(samples)
+		if err != nil {
+			return nil, err
+		}
+		length = detected
+	}
+
+	c.logger.Info("harmonizing read lengths",
+		zap.Int("samples", len(samples)),
+		zap.Int("length", length),
+	)
+
+	report := &models.HarmonizationReport{
+		CropLength: length,
+		Samples:    make([]*models.CropReport, 0, len(samples)),
+	}
+
+	for _, sample := range samples {
+		sampleReport, err := c.Crop(ctx, CropOptions{
+			SampleID:    sample.SampleID,
+			InputFile1:  sample.InputFile1,
+			InputFile2:  sample.InputFile2,
+			OutputDir:   sample.OutputDir,
+			Length:      length,
+			KeepShorter: keepShorter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("sample %s: %w", sample.SampleID, err)
+		}
+		report.Samples = append(report.Samples, sampleReport)
+		report.TotalBasesRemoved += sampleReport.BasesRemoved
+	}
+
+	return report, nil
+}
+
+// commonReadLength returns the shortest sequenced read length across samples.
+func (c *Cropper) commonReadLength(samples []HarmonizeSample) (int, error) {
+	common := 0
+	for _, sample := range samples {
+		files := []string{sample.InputFile1}
+		if sample.InputFile2 != "" {
+			files = append(files, sample.InputFile2)
+		}
+
+		for _, file := range files {
+			length, err := MaxReadLength(file)
+			if err != nil {
+				return 0, fmt.Errorf("detecting read length of %s: %w", sample.SampleID, err)
+			}
+			if common == 0 || length < common {
+				common = length
+			}
+		}
+	}
+
+	if common == 0 {
+		return 0, fmt.Errorf("could not detect read length")
+	}
+	return common, nil
+}
+
+// MaxReadLength returns the longest read among the first reads of a FASTQ
+// file, which approximates the sequenced read length after quality trimming.
+func MaxReadLength(filePath string) (int, error) {
+	r, err := openFASTQ(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	maxLen := 0
+	for i := 0; i < lengthSampleReads; i++ {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if len(rec.seq) > maxLen {
+			maxLen = len(rec.seq)
+		}
+	}
+
+	return maxLen, nil
+}
+
+// croppedFileName derives the output file name for a cropped FASTQ file.
+func croppedFileName(input string) string {
+	base := filepath.Base(input)
+	base = strings.TrimSuffix(base, ".gz")
+	base = strings.TrimSuffix(base, ".fastq")
+	base = strings.TrimSuffix(base, ".fq")
+	return base + "_cropped.fastq.gz"
+}
+
+// fastqReader reads FASTQ records from a plain or gzipped file.
+type fastqReader struct {
+	file    *os.File
+	gz      *gzip.Reader
+	scanner *bufio.Scanner
+}
+
+// openFASTQ opens a FASTQ file for reading, decompressing .gz files.
+func openFASTQ(filePath string) (*fastqReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	r := &fastqReader{file: file}
+	var reader io.Reader = file
+
+	if strings.HasSuffix(filePath, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("creating gzip reader: %w", err)
+		}
+		r.gz = gz
+		reader = gz
+	}
+
+	r.scanner = bufio.NewScanner(reader)
+	r.scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	return r, nil
+}
+
+// Next returns the next record, or io.EOF when the file is exhausted.
+func (r *fastqReader) Next() (*fastqRecord, error) {
+	var lines [4]string
+	for i := range lines {
+		if !r.scanner.Scan() {
+			if err := r.scanner.Err(); err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("truncated FASTQ record")
+		}
+		lines[i] = r.scanner.Text()
+	}
+
+	if !strings.HasPrefix(lines[0], "@") || !strings.HasPrefix(lines[2], "+") {
+		return nil, fmt.Errorf("malformed FASTQ record: %q", lines[0])
+	}
+	if len(lines[1]) != len(lines[3]) {
+		return nil, fmt.Errorf("sequence and quality lengths differ in record %q", lines[0])
+	}
+
+	return &fastqRecord{header: lines[0], seq: lines[1], plus: lines[2], quality: lines[3]}, nil
+}
+
+// Close releases the underlying file.
+func (r *fastqReader) Close() {
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	r.file.Close()
+}
+
+// fastqWriter writes gzipped FASTQ records.
+type fastqWriter struct {
+	file   *os.File
+	gz     *gzip.Writer
+	buf    *bufio.Writer
+	closed bool
+}
+
+// createFASTQ creates a gzipped FASTQ file for writing.
+func createFASTQ(filePath string) (*fastqWriter, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("creating output file: %w", err)
+	}
+
+	gz := gzip.NewWriter(file)
+	return &fastqWriter{
+		file: file,
+		gz:   gz,
+		buf:  bufio.NewWriterSize(gz, 1024*1024),
+	}, nil
+}
+
+// Write appends a record to the file.
+func (w *fastqWriter) Write(rec *fastqRecord) error {
+	_, err := fmt.Fprintf(w.buf, "%s\n%s\n%s\n%s\n", rec.header, rec.seq, rec.plus, rec.quality)
+	return err
+}
+
+// Close flushes buffered data and closes the file. It is safe to call twice.
+func (w *fastqWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
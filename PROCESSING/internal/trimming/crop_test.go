@@ -0,0 +1,177 @@
+package trimming
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// writeReads writes a plain FASTQ file with one read per sequence length.
+func writeReads(t *testing.T, path string, mate int, lengths ...int) {
+	t.Helper()
+	var b strings.Builder
+	for i, n := range lengths {
+		fmt.Fprintf(&b, "@r%d/%d\n%s\n+\n%s\n", i, mate, strings.Repeat("A", n), strings.Repeat("I", n))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRecords returns the header and sequence length of every record in a FASTQ file.
+func readRecords(t *testing.T, path string) []string {
+	t.Helper()
+	r, err := openFASTQ(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var records []string
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			break
+		}
+		name, _, _ := strings.Cut(rec.header, "/")
+		records = append(records, fmt.Sprintf("%s:%d", name, len(rec.seq)))
+	}
+	return records
+}
+
+func TestCrop(t *testing.T) {
+	tests := []struct {
+		name        string
+		reads1      []int
+		reads2      []int // nil for single-end
+		keepShorter bool
+		want        []string // name:length of the output reads, identical for both mates
+		dropped     int64
+		wantErr     string
+	}{
+		{
+			name:    "single-end drops short reads",
+			reads1:  []int{150, 80, 100, 99},
+			want:    []string{"@r0:100", "@r2:100"},
+			dropped: 2,
+		},
+		{
+			name:        "single-end keeps short reads",
+			reads1:      []int{150, 80},
+			keepShorter: true,
+			want:        []string{"@r0:100", "@r1:80"},
+		},
+		{
+			name:    "paired-end drops both mates of a short read",
+			reads1:  []int{150, 150, 60},
+			reads2:  []int{150, 90, 150},
+			want:    []string{"@r0:100"},
+			dropped: 2,
+		},
+		{
+			name:   "paired-end mates stay in lockstep",
+			reads1: []int{120, 130},
+			reads2: []int{140, 110},
+			want:   []string{"@r0:100", "@r1:100"},
+		},
+		{
+			name:    "mate count mismatch",
+			reads1:  []int{150, 150},
+			reads2:  []int{150},
+			wantErr: "different read counts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := CropOptions{
+				SampleID:    "s1",
+				InputFile1:  filepath.Join(dir, "s1_1.fastq"),
+				OutputDir:   filepath.Join(dir, "cropped"),
+				Length:      100,
+				KeepShorter: tt.keepShorter,
+			}
+			writeReads(t, opts.InputFile1, 1, tt.reads1...)
+			if tt.reads2 != nil {
+				opts.InputFile2 = filepath.Join(dir, "s1_2.fastq")
+				writeReads(t, opts.InputFile2, 2, tt.reads2...)
+			}
+
+			report, err := NewCropper(zap.NewNop()).Crop(context.Background(), opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if entries, _ := os.ReadDir(opts.OutputDir); len(entries) != 0 {
+					t.Errorf("output directory not empty after failure: %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.InputReads != int64(len(tt.reads1)) || report.DroppedReads != tt.dropped {
+				t.Errorf("input/dropped reads = %d/%d, want %d/%d",
+					report.InputReads, report.DroppedReads, len(tt.reads1), tt.dropped)
+			}
+			if report.OutputReads != int64(len(tt.want)) {
+				t.Errorf("output reads = %d, want %d", report.OutputReads, len(tt.want))
+			}
+			wantFiles := 1
+			if tt.reads2 != nil {
+				wantFiles = 2
+			}
+			if len(report.OutputFiles) != wantFiles {
+				t.Fatalf("output files = %v", report.OutputFiles)
+			}
+			for _, file := range report.OutputFiles {
+				if got := readRecords(t, file); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("%s = %v, want %v", filepath.Base(file), got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHarmonizeUsesShortestReadLength(t *testing.T) {
+	dir := t.TempDir()
+	samples := []HarmonizeSample{
+		{SampleID: "long", InputFile1: filepath.Join(dir, "long.fastq"), OutputDir: filepath.Join(dir, "long")},
+		{SampleID: "short", InputFile1: filepath.Join(dir, "short_1.fastq"), InputFile2: filepath.Join(dir, "short_2.fastq"), OutputDir: filepath.Join(dir, "short")},
+	}
+	writeReads(t, samples[0].InputFile1, 1, 150, 120, 90)
+	writeReads(t, samples[1].InputFile1, 1, 100, 80)
+	writeReads(t, samples[1].InputFile2, 2, 110, 100)
+
+	tests := []struct {
+		file string
+		want int
+	}{
+		{samples[0].InputFile1, 150},
+		{samples[1].InputFile1, 100},
+		{samples[1].InputFile2, 110},
+	}
+	for _, tt := range tests {
+		if got, err := MaxReadLength(tt.file); err != nil || got != tt.want {
+			t.Errorf("MaxReadLength(%s) = %d, %v, want %d", filepath.Base(tt.file), got, err, tt.want)
+		}
+	}
+
+	report, err := NewCropper(zap.NewNop()).Harmonize(context.Background(), samples, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.CropLength != 100 {
+		t.Errorf("crop length = %d, want 100", report.CropLength)
+	}
+	if got := []int64{report.Samples[0].OutputReads, report.Samples[1].OutputReads}; got[0] != 2 || got[1] != 1 {
+		t.Errorf("output reads = %v, want [2 1]", got)
+	}
+}
@@ -0,0 +1,266 @@
+package trimming
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guidiju-50/pandora/PROCESSING/internal/config"
+	"go.uber.org/zap"
+)
+
+// Trimmer trims reads. Trimmomatic and Fastp implement it.
+type Trimmer interface {
+	Run(ctx context.Context, opts Options) (*Result, error)
+}
+
+// Fastp provides a wrapper for the fastp tool.
+type Fastp struct {
+	config config.FastpConfig
+	logger *zap.Logger
+}
+
+// NewFastp creates a new fastp wrapper.
+func NewFastp(cfg config.FastpConfig, logger *zap.Logger) *Fastp {
+	return &Fastp{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// fastpReport is the part of the fastp JSON report read back.
+type fastpReport struct {
+	Summary struct {
+		BeforeFiltering struct {
+			TotalReads int64 `json:"total_reads"`
+		} `json:"before_filtering"`
+		AfterFiltering struct {
+			TotalReads int64 `json:"total_reads"`
+		} `json:"after_filtering"`
+	} `json:"summary"`
+}
+
+// Run executes fastp with the given options. Output files are named like
+// those of Trimmomatic so downstream steps handle both.
+func (f *Fastp) Run(ctx context.Context, opts Options) (*Result, error) {
+	startTime := time.Now()
+
+	if err := f.validateOptions(opts); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	isPaired := opts.InputFile2 != ""
+	outputFiles := outputFiles(opts, isPaired)
+	reportFile := filepath.Join(opts.OutputDir, outputBaseName(opts.InputFile1)+"_fastp.json")
+
+	args := f.buildArgs(opts, outputFiles, reportFile)
+	cmd := exec.CommandContext(ctx, f.path(), args...)
+	// Stop copying a streamed input shortly after fastp exits
+	cmd.WaitDelay = 5 * time.Second
+
+	// fastp reads the start of its first input to detect adapters and read
+	// length before trimming, which would consume reads from a named pipe.
+	// Read 1 is fed on stdin instead, where fastp skips that evaluation.
+	if opts.Streamed {
+		input, err := openStreamedInput(opts.InputFile1)
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", opts.InputFile1, err)
+		}
+		defer input.Close()
+		cmd.Stdin = input
+	}
+
+	f.logger.Info("running fastp",
+		zap.Bool("paired", isPaired),
+		zap.Bool("streamed", opts.Streamed),
+		zap.String("input1", opts.InputFile1),
+		zap.String("input2", opts.InputFile2),
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("fastp failed: %w: %s", err, strings.TrimSpace(lastLines(string(output), 5)))
+	}
+
+	result, err := readFastpReport(reportFile, isPaired)
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(startTime)
+	result.OutputFiles = outputFiles
+	result.LogFile = reportFile
+
+	f.logger.Info("fastp completed",
+		zap.Int64("input_reads", result.InputReads),
+		zap.Int64("output_reads", result.OutputReads),
+		zap.Float64("survival_rate", result.SurvivalRate),
+		zap.Duration("duration", result.Duration),
+	)
+
+	return result, nil
+}
+
+func (f *Fastp) path() string {
+	if f.config.Path == "" {
+		return "fastp"
+	}
+	return f.config.Path
+}
+
+// validateOptions validates trimming options.
+func (f *Fastp) validateOptions(opts Options) error {
+	if opts.InputFile1 == "" {
+		return fmt.Errorf("input file 1 is required")
+	}
+	if _, err := os.Stat(opts.InputFile1); err != nil {
+		return fmt.Errorf("input file 1 not found: %s", opts.InputFile1)
+	}
+	if opts.InputFile2 != "" {
+		if _, err := os.Stat(opts.InputFile2); err != nil {
+			return fmt.Errorf("input file 2 not found: %s", opts.InputFile2)
+		}
+	}
+	if opts.SlidingWindow != "" {
+		if _, _, err := parseSlidingWindow(opts.SlidingWindow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildArgs builds the command line arguments for fastp. Trimmomatic steps
+// map to fastp cuts: LEADING and TRAILING are front and tail cuts with a
+// one-base window, SLIDINGWINDOW is the right cut. Unset steps keep the
+// fastp defaults.
+func (f *Fastp) buildArgs(opts Options, outputFiles []string, reportFile string) []string {
+	input1 := opts.InputFile1
+	if opts.Streamed {
+		input1 = "/dev/stdin"
+	}
+	args := []string{"-i", input1, "-o", outputFiles[0]}
+	if opts.InputFile2 != "" {
+		args = append(args, "-I", opts.InputFile2, "-O", outputFiles[1], "--detect_adapter_for_pe")
+	}
+
+	threads := opts.Threads
+	if threads <= 0 {
+		threads = f.config.Threads
+	}
+	if threads > 0 {
+		args = append(args, "--thread", strconv.Itoa(threads))
+	}
+
+	if opts.AdapterFile != "" {
+		args = append(args, "--adapter_fasta", opts.AdapterFile)
+	}
+	if opts.Leading > 0 {
+		args = append(args, "--cut_front", "--cut_front_window_size", "1",
+			"--cut_front_mean_quality", strconv.Itoa(opts.Leading))
+	}
+	if opts.Trailing > 0 {
+		args = append(args, "--cut_tail", "--cut_tail_window_size", "1",
+			"--cut_tail_mean_quality", strconv.Itoa(opts.Trailing))
+	}
+	if window, quality, err := parseSlidingWindow(opts.SlidingWindow); err == nil {
+		args = append(args, "--cut_right", "--cut_right_window_size", strconv.Itoa(window),
+			"--cut_right_mean_quality", strconv.Itoa(quality))
+	}
+	if opts.MinLen > 0 {
+		args = append(args, "--length_required", strconv.Itoa(opts.MinLen))
+	}
+
+	return append(args,
+		"--json", reportFile,
+		"--html", strings.TrimSuffix(reportFile, ".json")+".html",
+	)
+}
+
+// parseSlidingWindow parses a Trimmomatic SLIDINGWINDOW value (size:quality).
+func parseSlidingWindow(value string) (int, int, error) {
+	size, quality, ok := strings.Cut(value, ":")
+	window, err1 := strconv.Atoi(size)
+	threshold, err2 := strconv.Atoi(quality)
+	if !ok || err1 != nil || err2 != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid sliding window %q, expected size:quality", value)
+	}
+	return window, threshold, nil
+}
+
+// readFastpReport reads read counts from a fastp JSON report. fastp counts
+// both mates of a pair; paired-end results count pairs like Trimmomatic.
+func readFastpReport(path string, isPaired bool) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fastp report: %w", err)
+	}
+	var report fastpReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parsing fastp report: %w", err)
+	}
+
+	result := &Result{
+		InputReads:  report.Summary.BeforeFiltering.TotalReads,
+		OutputReads: report.Summary.AfterFiltering.TotalReads,
+	}
+	if isPaired {
+		result.InputReads /= 2
+		result.OutputReads /= 2
+	}
+	result.DroppedReads = result.InputReads - result.OutputReads
+	if result.InputReads > 0 {
+		result.SurvivalRate = float64(result.OutputReads) / float64(result.InputReads) * 100
+	}
+	return result, nil
+}
+
+// openStreamedInput opens a named pipe for reading, decompressing it when its
+// name ends in .gz since fastp reads stdin as plain FASTQ.
+func openStreamedInput(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+	return &gzipPipe{file: file}, nil
+}
+
+// gzipPipe decompresses a named pipe. The gzip header is read on the first
+// Read so fastp starts before any data arrives.
+type gzipPipe struct {
+	file *os.File
+	gz   *gzip.Reader
+}
+
+func (p *gzipPipe) Read(b []byte) (int, error) {
+	if p.gz == nil {
+		gz, err := gzip.NewReader(p.file)
+		if err != nil {
+			return 0, err
+		}
+		p.gz = gz
+	}
+	return p.gz.Read(b)
+}
+
+func (p *gzipPipe) Close() error {
+	return p.file.Close()
+}
+
+// lastLines returns the last n lines of a command's output.
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,191 @@
+package trimming
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/guidiju-50/pandora/PROCESSING/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestFastpArgs(t *testing.T) {
+	f := NewFastp(config.FastpConfig{Threads: 4}, zap.NewNop())
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    []string // Argument sequences expected in order
+		exclude []string
+	}{
+		{
+			name: "single-end defaults",
+			opts: Options{InputFile1: "/in/SRR1.fastq.gz", OutputDir: "/out"},
+			want: []string{
+				"-i /in/SRR1.fastq.gz -o /out/SRR1_trimmed.fastq.gz",
+				"--thread 4",
+				"--json /out/SRR1_fastp.json --html /out/SRR1_fastp.html",
+			},
+			exclude: []string{"-I", "--cut_front", "--cut_tail", "--cut_right", "--length_required"},
+		},
+		{
+			name: "paired-end with Trimmomatic steps",
+			opts: Options{
+				InputFile1: "/in/SRR1_1.fastq.gz", InputFile2: "/in/SRR1_2.fastq.gz", OutputDir: "/out",
+				Leading: 3, Trailing: 5, SlidingWindow: "4:15", MinLen: 36, Threads: 8, AdapterFile: "/ad.fa",
+			},
+			want: []string{
+				"-i /in/SRR1_1.fastq.gz -o /out/SRR1_1_paired.fastq.gz -I /in/SRR1_2.fastq.gz -O /out/SRR1_2_paired.fastq.gz --detect_adapter_for_pe",
+				"--thread 8",
+				"--adapter_fasta /ad.fa",
+				"--cut_front --cut_front_window_size 1 --cut_front_mean_quality 3",
+				"--cut_tail --cut_tail_window_size 1 --cut_tail_mean_quality 5",
+				"--cut_right --cut_right_window_size 4 --cut_right_mean_quality 15",
+				"--length_required 36",
+			},
+		},
+		{
+			name: "streamed read 1 comes from stdin",
+			opts: Options{InputFile1: "/pipes/SRR1_1.fastq.gz", InputFile2: "/pipes/SRR1_2.fastq.gz", OutputDir: "/out", Streamed: true},
+			want: []string{
+				"-i /dev/stdin -o /out/SRR1_1_paired.fastq.gz -I /pipes/SRR1_2.fastq.gz",
+				"--json /out/SRR1_fastp.json",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isPaired := tt.opts.InputFile2 != ""
+			args := strings.Join(f.buildArgs(tt.opts, outputFiles(tt.opts, isPaired), filepath.Join(tt.opts.OutputDir, outputBaseName(tt.opts.InputFile1)+"_fastp.json")), " ")
+			rest := args
+			for _, want := range tt.want {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("args %q do not contain %q in order", args, want)
+				}
+				rest = rest[i+len(want):]
+			}
+			for _, flag := range tt.exclude {
+				if strings.Contains(" "+args+" ", " "+flag+" ") {
+					t.Errorf("args %q contain %s", args, flag)
+				}
+			}
+		})
+	}
+}
+
+func TestFastpValidateOptions(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "SRR1.fastq")
+	writeReads(t, input, 1, 4)
+	f := NewFastp(config.FastpConfig{}, zap.NewNop())
+
+	if err := f.validateOptions(Options{InputFile1: input, SlidingWindow: "4:15"}); err != nil {
+		t.Errorf("valid options: %v", err)
+	}
+	for _, opts := range []Options{
+		{},
+		{InputFile1: filepath.Join(dir, "missing.fastq")},
+		{InputFile1: input, InputFile2: filepath.Join(dir, "missing_2.fastq")},
+		{InputFile1: input, SlidingWindow: "4-15"},
+		{InputFile1: input, SlidingWindow: "0:15"},
+	} {
+		if err := f.validateOptions(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestReadFastpReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	report := `{"summary": {"before_filtering": {"total_reads": 2000, "total_bases": 200000},
+		"after_filtering": {"total_reads": 1800, "total_bases": 170000}}}`
+	if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		isPaired bool
+		input    int64
+		output   int64
+	}{
+		{"single-end counts reads", false, 2000, 1800},
+		{"paired-end counts pairs", true, 1000, 900},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := readFastpReport(path, tt.isPaired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.InputReads != tt.input || result.OutputReads != tt.output || result.DroppedReads != tt.input-tt.output {
+				t.Errorf("result = %+v", result)
+			}
+			if result.SurvivalRate != 90 {
+				t.Errorf("survival rate = %v, want 90", result.SurvivalRate)
+			}
+		})
+	}
+
+	if _, err := readFastpReport(filepath.Join(t.TempDir(), "missing.json"), false); err == nil {
+		t.Error("expected an error for a missing report")
+	}
+}
+
+func TestOpenStreamedInput(t *testing.T) {
+	const fastq = "@r1\nACGT\n+\nIIII\n@r2\nTTTT\n+\nIIII\n"
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		pipe    string
+		gzipped bool
+	}{
+		{"gzipped pipe is decompressed", "SRR1_1.fastq.gz", true},
+		{"plain pipe is read as is", "SRR1_1.fastq", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipe := filepath.Join(dir, tt.pipe)
+			if err := syscall.Mkfifo(pipe, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			// The writer opens the pipe like a download would
+			go func() {
+				out, err := os.OpenFile(pipe, os.O_WRONLY, 0)
+				if err != nil {
+					return
+				}
+				defer out.Close()
+				if !tt.gzipped {
+					out.Write([]byte(fastq))
+					return
+				}
+				gz := gzip.NewWriter(out)
+				gz.Write([]byte(fastq))
+				gz.Close()
+			}()
+
+			input, err := openStreamedInput(pipe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer input.Close()
+
+			got, err := io.ReadAll(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != fastq {
+				t.Errorf("read %q, want %q", got, fastq)
+			}
+		})
+	}
+}
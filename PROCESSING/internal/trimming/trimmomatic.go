@@ -43,6 +43,7 @@ type Options struct {
 	MinLen        int    // Minimum read length
 	Threads       int
 	AdapterFile   string // Path to adapter file
+	Streamed      bool   // Inputs are named pipes that can be read only once
 }
 
 // Result holds the result of a trimming operation.
@@ -104,7 +105,7 @@ func (t *Trimmomatic) Run(ctx context.Context, opts Options) (*Result, error) {
 	}
 
 	result.Duration = time.Since(startTime)
-	result.OutputFiles = t.getOutputFiles(opts, isPaired)
+	result.OutputFiles = outputFiles(opts, isPaired)
 
 	// Calculate survival rate
 	if result.InputReads > 0 {
@@ -175,11 +176,7 @@ func (t *Trimmomatic) buildArgs(opts Options, isPaired bool) []string {
 	}
 
 	// Add output files
-	baseName := strings.TrimSuffix(filepath.Base(opts.InputFile1), filepath.Ext(opts.InputFile1))
-	baseName = strings.TrimSuffix(baseName, ".fastq")
-	baseName = strings.TrimSuffix(baseName, ".fq")
-	baseName = strings.TrimSuffix(baseName, "_1")
-	baseName = strings.TrimSuffix(baseName, "_R1")
+	baseName := outputBaseName(opts.InputFile1)
 
 	if isPaired {
 		args = append(args,
@@ -294,13 +291,19 @@ func (t *Trimmomatic) parseOutputLine(line string, result *Result, isPaired bool
 	}
 }
 
-// getOutputFiles returns the list of output files.
-func (t *Trimmomatic) getOutputFiles(opts Options, isPaired bool) []string {
-	baseName := strings.TrimSuffix(filepath.Base(opts.InputFile1), filepath.Ext(opts.InputFile1))
+// outputBaseName derives the sample name of trimmed outputs from read 1
+// (SRR123_1.fastq.gz -> SRR123).
+func outputBaseName(inputFile1 string) string {
+	baseName := strings.TrimSuffix(filepath.Base(inputFile1), filepath.Ext(inputFile1))
 	baseName = strings.TrimSuffix(baseName, ".fastq")
 	baseName = strings.TrimSuffix(baseName, ".fq")
 	baseName = strings.TrimSuffix(baseName, "_1")
-	baseName = strings.TrimSuffix(baseName, "_R1")
+	return strings.TrimSuffix(baseName, "_R1")
+}
+
+// outputFiles returns the list of trimmed read files kept for downstream steps.
+func outputFiles(opts Options, isPaired bool) []string {
+	baseName := outputBaseName(opts.InputFile1)
 
 	if isPaired {
 		return []string{
@@ -0,0 +1,352 @@
+// Package discovery provides a registry of service replicas with health-checked selection.
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service names of the Pandora modules.
+const (
+	ServiceControl    = "control"
+	ServiceProcessing = "processing"
+	ServiceAnalysis   = "analysis"
+)
+
+// ErrNoEndpoints is returned when a service has no registered replicas.
+var ErrNoEndpoints = errors.New("no endpoints registered")
+
+// IdempotencyKeyHeader marks a request as safe to send again to another
+// replica even when its method is not idempotent.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Config holds registry configuration.
+type Config struct {
+	HealthPath     string        // Path probed on each replica (default /health)
+	HealthInterval time.Duration // Interval between health checks
+	HealthTimeout  time.Duration // Timeout of a single health probe
+}
+
+// Endpoint is a single replica of a service.
+type Endpoint struct {
+	URL       string     `json:"url"`
+	Healthy   bool       `json:"healthy"`
+	LastCheck *time.Time `json:"last_check,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// service holds the replicas of a service and its round-robin cursor.
+type service struct {
+	endpoints []*Endpoint
+	next      int
+}
+
+// Registry tracks replicas of the Pandora modules and selects healthy ones.
+type Registry struct {
+	config   Config
+	client   *http.Client
+	services map[string]*service
+	mu       sync.RWMutex
+	logger   *zap.Logger
+}
+
+// NewRegistry creates a new service registry.
+func NewRegistry(cfg Config, logger *zap.Logger) *Registry {
+	if cfg.HealthPath == "" {
+		cfg.HealthPath = "/health"
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = 15 * time.Second
+	}
+	if cfg.HealthTimeout <= 0 {
+		cfg.HealthTimeout = 3 * time.Second
+	}
+
+	return &Registry{
+		config:   cfg,
+		client:   &http.Client{Timeout: cfg.HealthTimeout},
+		services: make(map[string]*service),
+		logger:   logger,
+	}
+}
+
+// ParseURLs splits a comma-separated list of replica URLs.
+func ParseURLs(value string) []string {
+	var urls []string
+	for _, u := range strings.Split(value, ",") {
+		u = strings.TrimRight(strings.TrimSpace(u), "/")
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// Register adds replicas to a service. Replicas start out healthy until the
+// first health check says otherwise.
+func (r *Registry) Register(name string, urls ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, ok := r.services[name]
+	if !ok {
+		svc = &service{}
+		r.services[name] = svc
+	}
+
+	for _, u := range urls {
+		u = strings.TrimRight(u, "/")
+		if u == "" || svc.find(u) != nil {
+			continue
+		}
+		svc.endpoints = append(svc.endpoints, &Endpoint{URL: u, Healthy: true})
+	}
+
+	r.logger.Info("service registered", zap.String("service", name), zap.Strings("urls", urls))
+}
+
+// Services returns a snapshot of all registered services and their replicas.
+func (r *Registry) Services() map[string][]Endpoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string][]Endpoint, len(r.services))
+	for name, svc := range r.services {
+		endpoints := make([]Endpoint, 0, len(svc.endpoints))
+		for _, ep := range svc.endpoints {
+			endpoints = append(endpoints, *ep)
+		}
+		snapshot[name] = endpoints
+	}
+	return snapshot
+}
+
+// Endpoints returns the replica URLs of a service in selection order: healthy
+// replicas in round-robin order, followed by unhealthy ones as a last resort.
+func (r *Registry) Endpoints(name string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, ok := r.services[name]
+	if !ok || len(svc.endpoints) == 0 {
+		return nil
+	}
+
+	n := len(svc.endpoints)
+	start := svc.next % n
+	svc.next++
+
+	healthy := make([]string, 0, n)
+	unhealthy := make([]string, 0)
+	for i := 0; i < n; i++ {
+		ep := svc.endpoints[(start+i)%n]
+		if ep.Healthy {
+			healthy = append(healthy, ep.URL)
+		} else {
+			unhealthy = append(unhealthy, ep.URL)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// Resolve returns the preferred replica URL of a service.
+func (r *Registry) Resolve(name string) (string, error) {
+	endpoints := r.Endpoints(name)
+	if len(endpoints) == 0 {
+		return "", fmt.Errorf("service %s: %w", name, ErrNoEndpoints)
+	}
+	return endpoints[0], nil
+}
+
+// Do sends a request to a service, trying replicas in selection order. It
+// moves on to the next replica when the connection could not be established
+// or the replica answered 503, since the request never reached it. Other
+// transport errors (e.g. timeouts) and 502 or 504 responses may hide a
+// request that was processed, so they only fail over when the request is
+// retryable (see retryable). Any other response is returned as-is.
+func (r *Registry) Do(ctx context.Context, client *http.Client, name, method, path string, body []byte, header http.Header) (*http.Response, error) {
+	endpoints := r.Endpoints(name)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("service %s: %w", name, ErrNoEndpoints)
+	}
+
+	retry := retryable(method, header)
+
+	var lastErr error
+	for i, base := range endpoints {
+		if i > 0 {
+			r.logger.Warn("retrying against alternate instance",
+				zap.String("service", name),
+				zap.String("url", base),
+				zap.Error(lastErr),
+			)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, base+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		for key, values := range header {
+			for _, v := range values {
+				req.Header.Add(key, v)
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			r.setHealth(name, base, err)
+			if !retry && !isDialError(err) {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+
+		if isUnavailable(resp.StatusCode) {
+			err := fmt.Errorf("unavailable: status %d", resp.StatusCode)
+			r.setHealth(name, base, err)
+			if !retry && resp.StatusCode != http.StatusServiceUnavailable {
+				return resp, nil
+			}
+			resp.Body.Close()
+			lastErr = err
+			continue
+		}
+
+		r.setHealth(name, base, nil)
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("all instances of %s failed: %w", name, lastErr)
+}
+
+// CheckHealth probes every registered replica once.
+func (r *Registry) CheckHealth(ctx context.Context) {
+	for name, endpoints := range r.Services() {
+		for _, ep := range endpoints {
+			r.setHealth(name, ep.URL, r.probe(ctx, ep.URL))
+		}
+	}
+}
+
+// Start runs periodic health checks until the context is cancelled.
+func (r *Registry) Start(ctx context.Context) {
+	go func() {
+		r.CheckHealth(ctx)
+
+		ticker := time.NewTicker(r.config.HealthInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.CheckHealth(ctx)
+			}
+		}
+	}()
+}
+
+// probe performs a single health check against a replica.
+func (r *Registry) probe(ctx context.Context, base string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+r.config.HealthPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unhealthy: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// setHealth records the outcome of a health check or request against a replica.
+func (r *Registry) setHealth(name, url string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	svc, ok := r.services[name]
+	if !ok {
+		return
+	}
+	ep := svc.find(url)
+	if ep == nil {
+		return
+	}
+
+	now := time.Now()
+	ep.LastCheck = &now
+
+	if err != nil {
+		if ep.Healthy {
+			r.logger.Warn("service instance marked unhealthy",
+				zap.String("service", name),
+				zap.String("url", url),
+				zap.Error(err),
+			)
+		}
+		ep.Healthy = false
+		ep.LastError = err.Error()
+		return
+	}
+
+	if !ep.Healthy {
+		r.logger.Info("service instance recovered", zap.String("service", name), zap.String("url", url))
+	}
+	ep.Healthy = true
+	ep.LastError = ""
+}
+
+// find returns the replica with the given URL.
+func (s *service) find(url string) *Endpoint {
+	for _, ep := range s.endpoints {
+		if ep.URL == url {
+			return ep
+		}
+	}
+	return nil
+}
+
+// retryable reports whether a request may be sent to another replica after
+// an ambiguous failure: idempotent methods, or requests carrying an
+// idempotency key.
+func retryable(method string, header http.Header) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return header.Get(IdempotencyKeyHeader) != ""
+}
+
+// isDialError reports whether err happened while connecting, i.e. before
+// the request was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isUnavailable reports whether a status code indicates the instance itself is unavailable.
+func isUnavailable(status int) bool {
+	return status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
@@ -0,0 +1,86 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRegistryDoFailover(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		key        string
+		first      string // "down", "slow", or a status code served by the first replica
+		wantStatus int    // 0 when an error is expected
+		wantSecond bool   // whether the second replica must be reached
+	}{
+		{name: "POST fails over when the connection is refused", method: http.MethodPost, first: "down", wantStatus: http.StatusOK, wantSecond: true},
+		{name: "POST does not fail over on timeout", method: http.MethodPost, first: "slow"},
+		{name: "POST with idempotency key fails over on timeout", method: http.MethodPost, key: "abc", first: "slow", wantStatus: http.StatusOK, wantSecond: true},
+		{name: "GET fails over on timeout", method: http.MethodGet, first: "slow", wantStatus: http.StatusOK, wantSecond: true},
+		{name: "POST fails over on 503", method: http.MethodPost, first: "503", wantStatus: http.StatusOK, wantSecond: true},
+		{name: "POST returns 502 as-is", method: http.MethodPost, first: "502", wantStatus: http.StatusBadGateway},
+		{name: "GET fails over on 502", method: http.MethodGet, first: "502", wantStatus: http.StatusOK, wantSecond: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var secondHits int32
+			second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&secondHits, 1)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer second.Close()
+
+			first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch tt.first {
+				case "slow":
+					time.Sleep(300 * time.Millisecond)
+				case "502":
+					w.WriteHeader(http.StatusBadGateway)
+				case "503":
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer first.Close()
+			if tt.first == "down" {
+				first.Close()
+			}
+
+			registry := NewRegistry(Config{}, zap.NewNop())
+			registry.Register(ServiceProcessing, first.URL, second.URL)
+
+			header := http.Header{}
+			if tt.key != "" {
+				header.Set(IdempotencyKeyHeader, tt.key)
+			}
+			client := &http.Client{Timeout: 100 * time.Millisecond}
+			resp, err := registry.Do(context.Background(), client, ServiceProcessing, tt.method, "/", nil, header)
+
+			if tt.wantStatus == 0 {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("expected an error, got status %d", resp.StatusCode)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+
+			if got := atomic.LoadInt32(&secondHits) > 0; got != tt.wantSecond {
+				t.Errorf("second replica reached = %v, want %v", got, tt.wantSecond)
+			}
+		})
+	}
+}
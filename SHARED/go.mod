@@ -0,0 +1,7 @@
+module github.com/guidiju-50/pandora/SHARED
+
+go 1.21
+
+require go.uber.org/zap v1.26.0
+
+require go.uber.org/multierr v1.10.0 // indirect